@@ -0,0 +1,260 @@
+// Command ge loads a workflow definition, written in YAML or JSON, and
+// inspects or runs it.
+//
+// Usage:
+//
+//	ge list -f flow.yaml
+//	ge validate -f flow.yaml
+//	ge plan -f flow.yaml [-downstream] [target...]
+//	ge run -f flow.yaml [-v] [-downstream] [-state-dir dir -run-id id [-resume]] [-cache-dir dir] [target...]
+//
+// With targets, plan and run only consider them and the nodes they wait for,
+// or the nodes waiting for them with -downstream. Targets are node names, or
+// regexps matching them.
+//
+// With -cache-dir, run skips the nodes declaring inputs that did not change
+// since they last succeeded. The workflows in the directory are told apart by
+// the path of their file.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SakuraSa/ge/src/exe"
+	"github.com/SakuraSa/ge/src/flow"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// command is a subcommand of ge.
+type command struct {
+	usage string
+	run   func(ctx context.Context, args []string, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"list":     {"list -f file", list},
+	"validate": {"validate -f file", validate},
+	"plan":     {"plan -f file [-downstream] [target...]", plan},
+	"run":      {"run -f file [-v] [-downstream] [-state-dir dir -run-id id [-resume]] [-cache-dir dir] [target...]", runFlow},
+}
+
+// errUsage reports a command line that does not make sense.
+var errUsage = errors.New("usage")
+
+// run runs the command line args, and returns the exit code of ge.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "ge: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	if err := cmd.run(ctx, args[1:], stdout); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(stderr, "usage: ge %s\n", cmd.usage)
+			return 2
+		}
+		fmt.Fprintf(stderr, "ge: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "usage:")
+	for _, name := range names {
+		fmt.Fprintf(w, "\tge %s\n", commands[name].usage)
+	}
+}
+
+// load parses the flags of a command, with fs holding its own, and loads the
+// workflow they point to.
+func load(fs *flag.FlagSet, args []string) (exe.DAG, error) {
+	file := fs.String("f", "", "workflow definition, in YAML or JSON")
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil || *file == "" {
+		return exe.DAG{}, errUsage
+	}
+	return flow.Load(*file, nil)
+}
+
+// selectTargets returns the part of d the targets need, or the part that
+// needs them if downstream is true, or d without targets.
+func selectTargets(d exe.DAG, targets []string, downstream bool) (exe.DAG, error) {
+	switch {
+	case len(targets) == 0:
+		return d, nil
+	case downstream:
+		return d.SelectDownstream(targets...)
+	default:
+		return d.Select(targets...)
+	}
+}
+
+func list(_ context.Context, args []string, stdout io.Writer) error {
+	d, err := load(flag.NewFlagSet("list", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	nodes := d.Nodes()
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tDESCRIPTION\tLABELS")
+	for _, node := range nodes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", node.Name, node.Description, formatLabels(node.Labels))
+	}
+	return tw.Flush()
+}
+
+func validate(_ context.Context, args []string, stdout io.Writer) error {
+	d, err := load(flag.NewFlagSet("validate", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "ok: %d nodes\n", len(d.Nodes()))
+	return nil
+}
+
+func plan(_ context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	downstream := fs.Bool("downstream", false, "select the nodes waiting for the targets")
+	d, err := load(fs, args)
+	if err != nil {
+		return err
+	}
+	if d, err = selectTargets(d, fs.Args(), *downstream); err != nil {
+		return err
+	}
+	for i, stage := range d.Plan() {
+		fmt.Fprintf(stdout, "%d: %s\n", i+1, strings.Join(stage, " "))
+	}
+	return nil
+}
+
+func runFlow(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	verbose := fs.Bool("v", false, "print the events of the run")
+	downstream := fs.Bool("downstream", false, "select the nodes waiting for the targets")
+	stateDir := fs.String("state-dir", "", "directory recording the progress of the run")
+	runID := fs.String("run-id", "", "id of the run in the state directory")
+	resume := fs.Bool("resume", false, "skip the nodes that succeeded in the run")
+	cacheDir := fs.String("cache-dir", "", "directory recording the inputs of the nodes that succeeded")
+	d, err := load(fs, args)
+	if err != nil {
+		return err
+	}
+	if (*stateDir == "") != (*runID == "") || (*resume && *stateDir == "") {
+		return errUsage
+	}
+	if d, err = selectTargets(d, fs.Args(), *downstream); err != nil {
+		return err
+	}
+	if *stateDir != "" {
+		d = d.WithCheckpoint(exe.NewFileStateStore(*stateDir), *runID).WithResume(*resume)
+	}
+	if *cacheDir != "" {
+		workflow, err := filepath.Abs(fs.Lookup("f").Value.String())
+		if err != nil {
+			return err
+		}
+		d = d.WithFingerprintStore(exe.NewFileFingerprintStore(*cacheDir), workflow)
+	}
+	if *verbose {
+		ctx = exe.AddListener(ctx, exe.ListenerFunc(func(e exe.Event) {
+			printEvent(stdout, e)
+		}))
+	}
+
+	r := d.Start(ctx)
+	err = r.Wait()
+	printSummary(stdout, r.Report())
+	return err
+}
+
+// printEvent prints the node events of the outermost DAG.
+func printEvent(w io.Writer, e exe.Event) {
+	if e.Node == "" || len(e.Path) != 1 {
+		return
+	}
+	line := fmt.Sprintf("%s %-14s %s", e.Time.Format("15:04:05.000"), e.Type, e.Node)
+	switch e.Type {
+	case exe.NodeFinished, exe.NodeSkipped:
+		line += " " + e.State.String()
+	case exe.NodeRetried:
+		line += fmt.Sprintf(" attempt %d", e.Attempt)
+	}
+	if e.Err != nil {
+		line += ": " + e.Err.Error()
+	}
+	fmt.Fprintln(w, line)
+}
+
+// printSummary prints the state of every node of the run.
+func printSummary(w io.Writer, report exe.RunReport) {
+	nodes := append([]exe.NodeReport(nil), report.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tSTATE\tDURATION\tATTEMPTS\tERROR")
+	for _, node := range nodes {
+		state := node.State.String()
+		switch {
+		case node.Resumed:
+			state += " (resumed)"
+		case node.UpToDate:
+			state += " (up to date)"
+		}
+		errText := ""
+		if node.Err != nil {
+			errText = strings.ReplaceAll(node.Err.Error(), "\n", " ")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", node.Name, state, node.Duration().Round(time.Millisecond), node.Attempts, errText)
+	}
+	tw.Flush()
+
+	counts := make([]string, 0, 7)
+	for state := exe.Pending; state <= exe.Cancelled; state++ {
+		if n := report.Count(state); n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, state))
+		}
+	}
+	fmt.Fprintf(w, "%s in %s\n", strings.Join(counts, ", "), report.End.Sub(report.Start).Round(time.Millisecond))
+}
+
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
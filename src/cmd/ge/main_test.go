@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testFlow = `
+nodes:
+  - name: extract
+    type: noop
+  - name: transform
+    type: noop
+    depends_on: [extract]
+  - name: check
+    type: fail
+    depends_on: [extract]
+  - name: load
+    type: noop
+    depends_on: [transform, check]
+  - name: build
+    type: noop
+    inputs:
+      params: {version: 2}
+`
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "flow.yaml")
+	other := filepath.Join(dir, "other.yaml")
+	for _, path := range []string{file, other} {
+		if err := os.WriteFile(path, []byte(testFlow), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		want     []string
+	}{
+		{"no command", nil, 2, nil},
+		{"unknown command", []string{"fly"}, 2, nil},
+		{"no file", []string{"list"}, 2, nil},
+		{"list", []string{"list", "-f", file}, 0, []string{"build", "check", "extract", "load", "transform"}},
+		{"validate", []string{"validate", "-f", file}, 0, []string{"ok: 5 nodes"}},
+		{"plan", []string{"plan", "-f", file}, 0, []string{"1: build extract\n2: check transform\n3: load\n"}},
+		{"plan targets", []string{"plan", "-f", file, "transform"}, 0, []string{"1: extract\n2: transform\n"}},
+		{"plan downstream", []string{"plan", "-f", file, "-downstream", "^trans"}, 0, []string{"1: transform\n2: load\n"}},
+		{"unknown target", []string{"plan", "-f", file, "deploy"}, 1, nil},
+		{"run", []string{"run", "-v", "-f", file}, 1, []string{"node_finished  check failed", "load       cancelled", "3 succeeded, 1 failed, 1 cancelled"}},
+		{"run targets", []string{"run", "-f", file, "transform"}, 0, []string{"2 succeeded"}},
+		{"build", []string{"run", "-f", file, "-cache-dir", dir, "build"}, 0, []string{"build  succeeded  "}},
+		{"build up to date", []string{"run", "-f", file, "-cache-dir", dir, "build"}, 0, []string{"build  succeeded (up to date)"}},
+		{"build another workflow", []string{"run", "-f", other, "-cache-dir", dir, "build"}, 0, []string{"build  succeeded  "}},
+		{"resume without state", []string{"run", "-f", file, "-resume"}, 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			if code := run(context.Background(), tt.args, &stdout, &stderr); code != tt.wantCode {
+				t.Errorf("run() = %d, want %d, stderr = %s", code, tt.wantCode, stderr.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("run() stdout = %s, want %q in it", stdout.String(), want)
+				}
+			}
+		})
+	}
+}
@@ -15,3 +15,49 @@ func (a AOPs) Apply(f TaskFunc) TaskFunc {
 	}
 	return f
 }
+
+// TaskInfo describes a task run by an executor.
+type TaskInfo struct {
+	Meta
+	// Path holds the names of the enclosing tasks, outermost first, and ends
+	// with the name of the task.
+	Path []string
+	// Executor is the kind of executor running the task.
+	Executor string
+	// Index is the position of the task within its executor.
+	Index int
+	// Attempt is the attempt the task is run for, starting at 1.
+	Attempt int
+}
+
+// Aspect is an interface that defines an AOP concept aware of the task it
+// is applied to.
+type Aspect interface {
+	Wrap(TaskInfo, TaskFunc) TaskFunc
+}
+
+// AspectFunc is a function type that implements Aspect.
+type AspectFunc func(TaskInfo, TaskFunc) TaskFunc
+
+// Wrap calls a(info, f).
+func (a AspectFunc) Wrap(info TaskInfo, f TaskFunc) TaskFunc {
+	return a(info, f)
+}
+
+// Aspects is a slice of Aspect.
+type Aspects []Aspect
+
+// Wrap applies all Aspects to a TaskFunc.
+func (a Aspects) Wrap(info TaskInfo, f TaskFunc) TaskFunc {
+	for _, aspect := range a {
+		f = aspect.Wrap(info, f)
+	}
+	return f
+}
+
+// AspectOf adapts an AOP to an Aspect that ignores the task info.
+func AspectOf(aop AOP) Aspect {
+	return AspectFunc(func(_ TaskInfo, f TaskFunc) TaskFunc {
+		return aop.Apply(f)
+	})
+}
@@ -0,0 +1,75 @@
+package concept
+
+import "context"
+
+// Named is an interface that defines a task with a name.
+type Named interface {
+	Name() string
+}
+
+// Describer is an interface that defines a task that describes itself.
+type Describer interface {
+	Description() string
+	Labels() map[string]string
+}
+
+// Meta is the metadata of a task.
+type Meta struct {
+	Name        string
+	Description string
+	Labels      map[string]string
+}
+
+// MetaOf returns the metadata a task, of any kind, exposes through Named and
+// Describer.
+func MetaOf(task interface{}) Meta {
+	var meta Meta
+	if named, ok := task.(Named); ok {
+		meta.Name = named.Name()
+	}
+	if describer, ok := task.(Describer); ok {
+		meta.Description = describer.Description()
+		meta.Labels = describer.Labels()
+	}
+	return meta
+}
+
+// WithMeta returns a task that runs task and exposes meta through Named and
+// Describer.
+func WithMeta(task Task, meta Meta) Task {
+	return describedTask{task: task, meta: meta}
+}
+
+// WithName returns a task that runs task and is named name.
+func WithName(task Task, name string) Task {
+	meta := MetaOf(task)
+	meta.Name = name
+	return WithMeta(task, meta)
+}
+
+// describedTask is a task decorated with metadata.
+type describedTask struct {
+	task Task
+	meta Meta
+}
+
+func (t describedTask) Do(ctx context.Context) error {
+	return t.task.Do(ctx)
+}
+
+func (t describedTask) Name() string {
+	return t.meta.Name
+}
+
+func (t describedTask) Description() string {
+	return t.meta.Description
+}
+
+func (t describedTask) Labels() map[string]string {
+	return t.meta.Labels
+}
+
+// Unwrap returns the decorated task.
+func (t describedTask) Unwrap() Task {
+	return t.task
+}
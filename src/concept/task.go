@@ -9,3 +9,37 @@ type Task interface {
 
 // TaskFunc is a function type that defines a task that can be executed.
 type TaskFunc func(ctx context.Context) error
+
+// Do calls f(ctx).
+func (f TaskFunc) Do(ctx context.Context) error {
+	return f(ctx)
+}
+
+// TaskOf is an interface that defines a task that produces a result.
+type TaskOf[T any] interface {
+	Do(ctx context.Context) (T, error)
+}
+
+// TaskFuncOf is a function type that defines a task that produces a result.
+type TaskFuncOf[T any] func(ctx context.Context) (T, error)
+
+// Do calls f(ctx).
+func (f TaskFuncOf[T]) Do(ctx context.Context) (T, error) {
+	return f(ctx)
+}
+
+// Discard adapts a TaskOf to a Task that drops its result. The Task exposes
+// the same metadata.
+func Discard[T any](task TaskOf[T]) Task {
+	return WithMeta(TaskFunc(func(ctx context.Context) error {
+		_, err := task.Do(ctx)
+		return err
+	}), MetaOf(task))
+}
+
+// Lift adapts a Task to a TaskOf with an empty result.
+func Lift(task Task) TaskOf[struct{}] {
+	return TaskFuncOf[struct{}](func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, task.Do(ctx)
+	})
+}
@@ -0,0 +1,181 @@
+package exe
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheEntry is the outcome of a task kept by a Cache.
+type CacheEntry struct {
+	// Value is the result of the task, if it has one. Caches that encode
+	// their entries return it as a json.RawMessage.
+	Value interface{}
+	// Err is the error of the task, for negative entries. Caches that
+	// encode their entries only keep its message.
+	Err error
+	// Expires is when the entry stops being valid; zero means never.
+	Expires time.Time
+}
+
+// expired reports whether e is no longer valid at now.
+func (e CacheEntry) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !now.Before(e.Expires)
+}
+
+// Cache keeps the outcomes of tasks by key for Memo. Caches are used by
+// concurrent tasks, and treat their own failures as misses.
+type Cache interface {
+	// Get returns the entry of key, and false if there is no valid one.
+	Get(key string) (CacheEntry, bool)
+	// Set sets the entry of key.
+	Set(key string, entry CacheEntry)
+}
+
+// LRUCache is a Cache in memory, which evicts the least recently used
+// entries beyond its capacity.
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+var _ Cache = (*LRUCache)(nil)
+
+// lruItem is an entry of an LRUCache.
+type lruItem struct {
+	key   string
+	entry CacheEntry
+}
+
+// NewLRUCache returns an LRUCache holding up to capacity entries, or an
+// unbounded number if capacity is zero or less.
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *LRUCache) Get(key string) (CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return CacheEntry{}, false
+	}
+	item := elem.Value.(*lruItem)
+	if item.entry.expired(time.Now()) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return CacheEntry{}, false
+	}
+	c.order.MoveToFront(elem)
+	return item.entry, true
+}
+
+func (c *LRUCache) Set(key string, entry CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*lruItem).entry = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruItem{key: key, entry: entry})
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruItem).key)
+	}
+}
+
+// Len returns the number of entries in c, expired ones included.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// DiskCache is a Cache keeping every entry in a JSON file of its directory,
+// so that entries outlive the program. Values are encoded in JSON, and
+// errors are reduced to their message.
+type DiskCache struct {
+	mu  sync.Mutex
+	dir string
+}
+
+var _ Cache = (*DiskCache)(nil)
+
+// diskEntry is the encoding of a CacheEntry by a DiskCache.
+type diskEntry struct {
+	Key     string          `json:"key"`
+	Value   json.RawMessage `json:"value,omitempty"`
+	Err     string          `json:"err,omitempty"`
+	Failed  bool            `json:"failed,omitempty"`
+	Expires time.Time       `json:"expires,omitempty"`
+}
+
+// NewDiskCache returns a DiskCache keeping its files in dir, which is
+// created when needed.
+func NewDiskCache(dir string) *DiskCache {
+	return &DiskCache{dir: dir}
+}
+
+func (c *DiskCache) Get(key string) (CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return CacheEntry{}, false
+	}
+	var stored diskEntry
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Key != key {
+		return CacheEntry{}, false
+	}
+	entry := CacheEntry{Expires: stored.Expires}
+	if entry.expired(time.Now()) {
+		os.Remove(c.path(key))
+		return CacheEntry{}, false
+	}
+	if stored.Value != nil {
+		entry.Value = stored.Value
+	}
+	if stored.Failed {
+		entry.Err = errors.New(stored.Err)
+	}
+	return entry, true
+}
+
+func (c *DiskCache) Set(key string, entry CacheEntry) {
+	stored := diskEntry{Key: key, Expires: entry.Expires}
+	if entry.Value != nil {
+		value, err := json.Marshal(entry.Value)
+		if err != nil {
+			return
+		}
+		stored.Value = value
+	}
+	if entry.Err != nil {
+		stored.Err = entry.Err.Error()
+		stored.Failed = true
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writeFile(c.path(key), data)
+}
+
+// path returns the file of key, named after its digest as keys may be long.
+func (c *DiskCache) path(key string) string {
+	return filepath.Join(c.dir, hashBytes([]byte(key))+".json")
+}
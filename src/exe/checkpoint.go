@@ -0,0 +1,225 @@
+package exe
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrStateNotFound = errors.New("no state recorded for run")
+)
+
+// RunState is what a StateStore records about a DAG run: the nodes that
+// succeeded in it, their outputs, and the graph they belong to.
+type RunState struct {
+	RunID       string `json:"run_id"`
+	Fingerprint string `json:"fingerprint"`
+	// Succeeded maps the nodes that succeeded to when they did.
+	Succeeded map[string]time.Time `json:"succeeded"`
+	// Outputs maps the nodes that succeeded and set their output to that
+	// output, encoded in JSON.
+	Outputs map[string]json.RawMessage `json:"outputs,omitempty"`
+}
+
+// StateStore records the progress of DAG runs, so that a failed run can be
+// resumed where it stopped.
+type StateStore interface {
+	// Load returns the state recorded for runID, or ErrStateNotFound.
+	Load(ctx context.Context, runID string) (RunState, error)
+	// Save records state, replacing the one recorded for the same run.
+	Save(ctx context.Context, state RunState) error
+}
+
+// FileStateStore is a StateStore keeping the state of every run in a JSON
+// file of its directory.
+type FileStateStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+var _ StateStore = (*FileStateStore)(nil)
+
+// NewFileStateStore returns a FileStateStore keeping its files in dir, which
+// is created when needed.
+func NewFileStateStore(dir string) *FileStateStore {
+	return &FileStateStore{dir: dir}
+}
+
+func (s *FileStateStore) Load(_ context.Context, runID string) (RunState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.path(runID))
+	if errors.Is(err, os.ErrNotExist) {
+		return RunState{}, fmt.Errorf("%w: %s", ErrStateNotFound, runID)
+	}
+	if err != nil {
+		return RunState{}, err
+	}
+	var state RunState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return RunState{}, fmt.Errorf("state of run %s: %w", runID, err)
+	}
+	return state, nil
+}
+
+// Save writes state to a temporary file first, so that a crash never leaves
+// a truncated state behind.
+func (s *FileStateStore) Save(_ context.Context, state RunState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeFile(s.path(state.RunID), data)
+}
+
+// writeFile writes data to the file at path, through a temporary file
+// renamed once it is complete, and creates its directory when needed.
+func writeFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// path returns the file of the run runID.
+func (s *FileStateStore) path(runID string) string {
+	return filepath.Join(s.dir, url.PathEscape(runID)+".json")
+}
+
+// WithCheckpoint returns a copy of d that records the nodes succeeding in the
+// run runID in store.
+func (d DAG) WithCheckpoint(store StateStore, runID string) DAG {
+	d.store = store
+	d.runID = runID
+	return d
+}
+
+// WithResume returns a copy of d that, if resume is true, does not run again
+// the nodes its checkpoint recorded as succeeded, provided the graph has the
+// same Fingerprint. The outputs of these nodes are restored from the
+// checkpoint; producers whose output could not be recorded run again.
+func (d DAG) WithResume(resume bool) DAG {
+	d.resume = resume
+	return d
+}
+
+// Fingerprint returns a digest of the names of the nodes of d and of the
+// edges between them, which changes when the shape of the graph does.
+func (d DAG) Fingerprint() string {
+	lines := make([]string, len(d.nodes))
+	for i := range d.nodes {
+		next := make([]string, len(d.edges[i]))
+		for j, index := range d.edges[i] {
+			next[j] = strconv.Quote(d.name(index))
+		}
+		sort.Strings(next)
+		lines[i] = strconv.Quote(d.name(i)) + " -> " + strings.Join(next, " ")
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkpoint is the state of a run recorded in a StateStore.
+type checkpoint struct {
+	store StateStore
+	state RunState
+}
+
+// loadCheckpoint returns the checkpoint of a run of d, which starts from the
+// recorded state when d resumes a run of the same graph.
+func loadCheckpoint(ctx context.Context, d DAG) (*checkpoint, error) {
+	if d.store == nil {
+		return nil, nil
+	}
+	c := &checkpoint{
+		store: d.store,
+		state: RunState{
+			RunID:       d.runID,
+			Fingerprint: d.Fingerprint(),
+			Succeeded:   make(map[string]time.Time),
+			Outputs:     make(map[string]json.RawMessage),
+		},
+	}
+	if !d.resume {
+		return c, nil
+	}
+	state, err := d.store.Load(ctx, d.runID)
+	switch {
+	case errors.Is(err, ErrStateNotFound):
+		return c, nil
+	case err != nil:
+		return nil, fmt.Errorf("checkpoint %s: %w", d.runID, err)
+	}
+	if state.Fingerprint == c.state.Fingerprint {
+		for node, at := range state.Succeeded {
+			c.state.Succeeded[node] = at
+		}
+		for node, output := range state.Outputs {
+			c.state.Outputs[node] = output
+		}
+	}
+	return c, nil
+}
+
+// succeeded reports whether node was recorded as succeeded.
+func (c *checkpoint) succeeded(node string) bool {
+	if c == nil {
+		return false
+	}
+	_, ok := c.state.Succeeded[node]
+	return ok
+}
+
+// output returns the recorded output of node, and false if there is none.
+func (c *checkpoint) output(node string) (json.RawMessage, bool) {
+	if c == nil {
+		return nil, false
+	}
+	output, ok := c.state.Outputs[node]
+	return output, ok
+}
+
+// record records that node succeeded, with its output if it is not nil.
+func (c *checkpoint) record(ctx context.Context, node string, output json.RawMessage) error {
+	if c == nil {
+		return nil
+	}
+	c.state.Succeeded[node] = time.Now()
+	if output != nil {
+		c.state.Outputs[node] = output
+	} else {
+		delete(c.state.Outputs, node)
+	}
+	if err := c.store.Save(ctx, c.state); err != nil {
+		return fmt.Errorf("checkpoint %s: %w", c.state.RunID, err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package exe
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+func TestFileStateStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileStateStore(t.TempDir())
+	if _, err := store.Load(ctx, "nightly/1"); !errors.Is(err, ErrStateNotFound) {
+		t.Errorf("FileStateStore.Load() error = %v, want %v", err, ErrStateNotFound)
+	}
+	state := RunState{RunID: "nightly/1", Fingerprint: "f", Succeeded: map[string]time.Time{"a": time.Unix(1, 0)}}
+	if err := store.Save(ctx, state); err != nil {
+		t.Errorf("FileStateStore.Save() error = %v", err)
+	}
+	got, err := store.Load(ctx, "nightly/1")
+	if err != nil || got.Fingerprint != "f" || !got.Succeeded["a"].Equal(time.Unix(1, 0)) {
+		t.Errorf("FileStateStore.Load() = %+v, %v", got, err)
+	}
+}
+
+func TestDAG_Fingerprint(t *testing.T) {
+	build := func(edges map[string][]string) DAG {
+		b := NewDAGBuilder()
+		for name, next := range edges {
+			b.AddNode(name, T(func(ctx context.Context) error { return nil }), next...)
+		}
+		d, err := b.Build()
+		if err != nil {
+			t.Fatalf("DAG.Build() error = %v", err)
+		}
+		return d
+	}
+	shape := map[string][]string{"a": {"b", "c"}, "b": {"c"}, "c": nil}
+	want := build(shape).Fingerprint()
+	for i := 0; i < 10; i++ {
+		if got := build(shape).Fingerprint(); got != want {
+			t.Errorf("DAG.Fingerprint() = %s, want %s", got, want)
+		}
+	}
+	if got := build(map[string][]string{"a": {"b"}, "b": {"c"}, "c": nil}).Fingerprint(); got == want {
+		t.Errorf("DAG.Fingerprint() does not change with the edges")
+	}
+}
+
+func TestDAG_Resume(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		ran  map[string]int
+		fail bool
+	)
+	node := func(name string) concept.Task {
+		return T(func(ctx context.Context) error {
+			mu.Lock()
+			ran[name]++
+			mu.Unlock()
+			if name == "b" && fail {
+				return errTest
+			}
+			return nil
+		})
+	}
+	b := NewDAGBuilder()
+	b.AddNode("a", node("a"), "b")
+	b.AddNode("b", node("b"), "c")
+	b.AddNode("c", node("c"))
+	d, err := b.Build()
+	if err != nil {
+		t.Errorf("DAG.Build() error = %v", err)
+		return
+	}
+	store := NewFileStateStore(t.TempDir())
+	d = d.WithCheckpoint(store, "run-1")
+
+	ran, fail = map[string]int{}, true
+	if err := d.Do(context.Background()); !errors.Is(err, errTest) {
+		t.Errorf("DAG.Do() error = %v, want %v", err, errTest)
+	}
+
+	ran, fail = map[string]int{}, false
+	run := d.WithResume(true).Start(context.Background())
+	if err := run.Wait(); err != nil {
+		t.Errorf("Run.Wait() error = %v", err)
+	}
+	if ran["a"] != 0 || ran["b"] != 1 || ran["c"] != 1 {
+		t.Errorf("resumed DAG.Do() ran %v, want b and c", ran)
+	}
+	report := run.Report()
+	if a, _ := report.Node("a"); a.State != Succeeded || !a.Resumed {
+		t.Errorf("RunReport.Node(%q) = %+v, want resumed", "a", a)
+	}
+
+	ran = map[string]int{}
+	if err := d.WithResume(true).Do(context.Background()); err != nil {
+		t.Errorf("DAG.Do() error = %v", err)
+	}
+	if len(ran) != 0 {
+		t.Errorf("DAG.Do() of a completed run ran %v", ran)
+	}
+
+	b.AddNode("d", node("d"))
+	changed, err := b.Build()
+	if err != nil {
+		t.Errorf("DAG.Build() error = %v", err)
+		return
+	}
+	ran = map[string]int{}
+	if err := changed.WithCheckpoint(store, "run-1").WithResume(true).Do(context.Background()); err != nil {
+		t.Errorf("DAG.Do() error = %v", err)
+	}
+	if len(ran) != 4 {
+		t.Errorf("DAG.Do() of a changed graph ran %v, want every node", ran)
+	}
+}
+
+func TestDAG_ResumeOutputs(t *testing.T) {
+	var (
+		ran  int
+		got  []string
+		fail bool
+	)
+	out := NewOutput[[]string]("a")
+	b := NewDAGBuilder()
+	b.AddNodeWithOptions("a", Produce[[]string](out, concept.TaskFuncOf[[]string](func(ctx context.Context) ([]string, error) {
+		ran++
+		return []string{"x", "y"}, nil
+	})), Produces(out))
+	b.AddNodeWithOptions("b", T(func(ctx context.Context) error {
+		if fail {
+			return errTest
+		}
+		var err error
+		got, err = out.Get(ctx)
+		return err
+	}), DependsOn("a"), Consumes(out))
+	d, err := b.Build()
+	if err != nil {
+		t.Errorf("DAG.Build() error = %v", err)
+		return
+	}
+	d = d.WithCheckpoint(NewFileStateStore(t.TempDir()), "run-1").WithResume(true)
+
+	fail = true
+	if err := d.Do(context.Background()); !errors.Is(err, errTest) {
+		t.Errorf("DAG.Do() error = %v, want %v", err, errTest)
+	}
+	fail = false
+	if err := d.Do(context.Background()); err != nil {
+		t.Errorf("resumed DAG.Do() error = %v", err)
+	}
+	if ran != 1 || len(got) != 2 || got[0] != "x" || got[1] != "y" {
+		t.Errorf("resumed DAG.Do() ran a %d times and read %v, want once and [x y]", ran, got)
+	}
+}
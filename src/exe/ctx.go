@@ -2,6 +2,7 @@ package exe
 
 import (
 	"context"
+	"sync/atomic"
 
 	"github.com/SakuraSa/ge/src/concept"
 )
@@ -12,8 +13,55 @@ const (
 	AOPKey AOPKeyType = "AOP"
 )
 
+type AspectKeyType string
+
+const (
+	AspectKey AspectKeyType = "Aspect"
+)
+
+type LimiterKeyType string
+
+const (
+	LimiterKey LimiterKeyType = "Limiter"
+)
+
+type TaskInfoKeyType string
+
+const (
+	TaskInfoKey TaskInfoKeyType = "TaskInfo"
+)
+
+type ListenerKeyType string
+
+const (
+	ListenerKey ListenerKeyType = "Listener"
+)
+
+type attemptKeyType struct{}
+
+var attemptKey attemptKeyType
+
+type PanicPolicyKeyType string
+
+const (
+	PanicPolicyKey PanicPolicyKeyType = "PanicPolicy"
+)
+
+// PanicPolicy decides what executors do when a task panics.
+type PanicPolicy int
+
+const (
+	// RecoverPanics turns panics into *PanicError failures.
+	RecoverPanics PanicPolicy = iota
+	// RePanic lets panics propagate, which is handy when debugging a task.
+	// Note that a panic in a task run on its own goroutine crashes the
+	// program.
+	RePanic
+)
+
 var (
-	nilAOPs concept.AOPs = nil
+	nilAOPs    concept.AOPs    = nil
+	nilAspects concept.Aspects = nil
 )
 
 // GetAOP returns the AOP in the context.
@@ -29,3 +77,94 @@ func GetAOP(ctx context.Context) concept.AOP {
 func SetAOP(ctx context.Context, aop concept.AOP) context.Context {
 	return context.WithValue(ctx, AOPKey, aop)
 }
+
+// GetAspect returns the Aspect in the context. Executors apply it around the
+// AOP in the context.
+func GetAspect(ctx context.Context) concept.Aspect {
+	aspect, ok := ctx.Value(AspectKey).(concept.Aspect)
+	if !ok {
+		return nilAspects
+	}
+	return aspect
+}
+
+// SetAspect sets the Aspect in the context.
+func SetAspect(ctx context.Context, aspect concept.Aspect) context.Context {
+	return context.WithValue(ctx, AspectKey, aspect)
+}
+
+// GetLimiter returns the Limiter in the context, which bounds the number of
+// tasks running at the same time across all executors.
+func GetLimiter(ctx context.Context) *Limiter {
+	limiter, _ := ctx.Value(LimiterKey).(*Limiter)
+	return limiter
+}
+
+// SetLimiter sets the Limiter in the context.
+func SetLimiter(ctx context.Context, limiter *Limiter) context.Context {
+	return context.WithValue(ctx, LimiterKey, limiter)
+}
+
+// GetPanicPolicy returns the PanicPolicy in the context.
+func GetPanicPolicy(ctx context.Context) PanicPolicy {
+	policy, _ := ctx.Value(PanicPolicyKey).(PanicPolicy)
+	return policy
+}
+
+// SetPanicPolicy sets the PanicPolicy in the context.
+func SetPanicPolicy(ctx context.Context, policy PanicPolicy) context.Context {
+	return context.WithValue(ctx, PanicPolicyKey, policy)
+}
+
+// GetTaskInfo returns the description of the task being run in the context.
+func GetTaskInfo(ctx context.Context) (concept.TaskInfo, bool) {
+	info, ok := ctx.Value(TaskInfoKey).(concept.TaskInfo)
+	return info, ok
+}
+
+// SetTaskInfo sets the description of the task being run in the context.
+func SetTaskInfo(ctx context.Context, info concept.TaskInfo) context.Context {
+	return context.WithValue(ctx, TaskInfoKey, info)
+}
+
+// GetListener returns the listeners in the context.
+func GetListener(ctx context.Context) Listeners {
+	listeners, _ := ctx.Value(ListenerKey).(Listeners)
+	return listeners
+}
+
+// AddListener adds listener to the ones in the context. Executors emit their
+// events to every listener in their context. Each listener receives them in
+// order, on a goroutine of its own, so a slow listener delays its events but
+// not the executors, until the outermost executor returns: it waits for the
+// listeners to have handled every event, RunFinished included.
+func AddListener(ctx context.Context, listener Listener) context.Context {
+	listeners := append(Listeners(nil), GetListener(ctx)...)
+	listeners = append(listeners, newAsyncListener(listener))
+	return context.WithValue(ctx, ListenerKey, listeners)
+}
+
+// GetAttempt returns the attempt the task in the context is run for,
+// starting at 1.
+func GetAttempt(ctx context.Context) int {
+	if info, ok := GetTaskInfo(ctx); ok && info.Attempt > 0 {
+		return info.Attempt
+	}
+	return 1
+}
+
+// withAttempts returns a context in which setAttempt records the attempt the
+// current task is run for, and a function returning the last one recorded.
+func withAttempts(ctx context.Context, first int) (context.Context, func() int) {
+	attempt := int32(first)
+	return context.WithValue(ctx, attemptKey, &attempt), func() int {
+		return int(atomic.LoadInt32(&attempt))
+	}
+}
+
+// setAttempt records the attempt the task is run for in the context.
+func setAttempt(ctx context.Context, attempt int) {
+	if recorded, ok := ctx.Value(attemptKey).(*int32); ok {
+		atomic.StoreInt32(recorded, int32(attempt))
+	}
+}
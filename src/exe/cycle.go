@@ -0,0 +1,108 @@
+package exe
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	_ error = (*CycleError)(nil)
+
+	// MaxReportedCycles bounds the number of cycles listed in a CycleError.
+	MaxReportedCycles = 32
+)
+
+// CycleError is the error reported when a DAG has cycles. It matches
+// ErrCycle.
+type CycleError struct {
+	// Path is the first cycle found, as the names of its nodes in the order
+	// they wait for each other.
+	Path []string
+	// Cycles lists the elementary cycles of the DAG, up to
+	// MaxReportedCycles of them.
+	Cycles [][]string
+}
+
+func (e *CycleError) Error() string {
+	if len(e.Path) == 0 {
+		return ErrCycle.Error()
+	}
+	msg := fmt.Sprintf("%v: %s -> %s", ErrCycle, strings.Join(e.Path, " -> "), e.Path[0])
+	if len(e.Cycles) > 1 {
+		msg += fmt.Sprintf(" (%d cycles found)", len(e.Cycles))
+	}
+	return msg
+}
+
+// Is reports whether target is ErrCycle.
+func (e *CycleError) Is(target error) bool {
+	return target == ErrCycle
+}
+
+// cycleNames returns the names of the nodes of cycle.
+func (d DAG) cycleNames(cycle []int) []string {
+	names := make([]string, len(cycle))
+	for i, index := range cycle {
+		names[i] = d.name(index)
+	}
+	return names
+}
+
+// elementaryCycles returns up to limit elementary cycles of the graph, each
+// starting from its lowest node, using Johnson's algorithm.
+func elementaryCycles(edges [][]int, limit int) [][]int {
+	var (
+		n        = len(edges)
+		cycles   [][]int
+		stack    []int
+		blocked  = make([]bool, n)
+		blockMap = make([]map[int]bool, n)
+		start    int
+		unblock  func(int)
+		circuit  func(int) bool
+	)
+	unblock = func(v int) {
+		blocked[v] = false
+		for w := range blockMap[v] {
+			delete(blockMap[v], w)
+			if blocked[w] {
+				unblock(w)
+			}
+		}
+	}
+	circuit = func(v int) bool {
+		found := false
+		stack = append(stack, v)
+		blocked[v] = true
+		for _, w := range edges[v] {
+			if w < start || w >= n || len(cycles) >= limit {
+				continue
+			}
+			if w == start {
+				cycles = append(cycles, append([]int(nil), stack...))
+				found = true
+			} else if !blocked[w] && circuit(w) {
+				found = true
+			}
+		}
+		if found {
+			unblock(v)
+		} else {
+			for _, w := range edges[v] {
+				if w >= start && w < n {
+					blockMap[w][v] = true
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		return found
+	}
+	for start = 0; start < n && len(cycles) < limit; start++ {
+		for v := start; v < n; v++ {
+			blocked[v] = false
+			blockMap[v] = make(map[int]bool)
+		}
+		circuit(start)
+	}
+	return cycles
+}
@@ -0,0 +1,111 @@
+package exe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func Test_elementaryCycles(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges [][]int
+		limit int
+		want  string
+	}{
+		{
+			name:  "acyclic",
+			edges: [][]int{{1, 2}, {2}, {}},
+			limit: 10,
+			want:  "[]",
+		},
+		{
+			name:  "self loop",
+			edges: [][]int{{0}},
+			limit: 10,
+			want:  "[[0]]",
+		},
+		{
+			name:  "complete",
+			edges: [][]int{{1, 2}, {0, 2}, {0, 1}},
+			limit: 10,
+			want:  "[[0 1] [0 1 2] [0 2] [0 2 1] [1 2]]",
+		},
+		{
+			name:  "limit",
+			edges: [][]int{{1, 2}, {0, 2}, {0, 1}},
+			limit: 2,
+			want:  "[[0 1] [0 1 2]]",
+		},
+		{
+			name:  "disjoint",
+			edges: [][]int{{1}, {0}, {3}, {4}, {2}},
+			limit: 10,
+			want:  "[[0 1] [2 3 4]]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprint(elementaryCycles(tt.edges, tt.limit)); got != tt.want {
+				t.Errorf("elementaryCycles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCycleError(t *testing.T) {
+	b := NewDAGBuilder()
+	for _, name := range []string{"a", "b", "c", "d"} {
+		b.AddNode(name, T(func(ctx context.Context) error {
+			return nil
+		}))
+	}
+	b.AddNodeWithOptions("x", T(func(ctx context.Context) error {
+		return nil
+	}), DependsOn("c"), Before("a"))
+	b.AddNode("a", T(func(ctx context.Context) error {
+		return nil
+	}), "b")
+	b.AddNode("b", T(func(ctx context.Context) error {
+		return nil
+	}), "c", "d")
+	b.AddNode("d", T(func(ctx context.Context) error {
+		return nil
+	}), "b")
+
+	_, err := b.Build()
+	if !errors.Is(err, ErrCycle) {
+		t.Errorf("DAG.Build() error = %v, want %v", err, ErrCycle)
+		return
+	}
+	var cycleErr *CycleError
+	if !errors.As(err, &cycleErr) {
+		t.Errorf("DAG.Build() error = %v, want a *CycleError", err)
+		return
+	}
+	if len(cycleErr.Path) < 2 || !strings.Contains(err.Error(), strings.Join(cycleErr.Path, " -> ")) {
+		t.Errorf("CycleError = %v, path %v", err, cycleErr.Path)
+	}
+	var cycles []string
+	for _, cycle := range cycleErr.Cycles {
+		// rotate the cycle to start from its smallest name
+		first := 0
+		for i := range cycle {
+			if cycle[i] < cycle[first] {
+				first = i
+			}
+		}
+		cycles = append(cycles, strings.Join(append(cycle[first:], cycle[:first]...), ","))
+	}
+	sort.Strings(cycles)
+	got := strings.Join(cycles, " ")
+	if want := "a,b,c,x b,d"; got != want {
+		t.Errorf("CycleError.Cycles = %v, want %v", got, want)
+	}
+	if got := (&CycleError{}).Error(); got != ErrCycle.Error() {
+		t.Errorf("CycleError{}.Error() = %v, want %v", got, ErrCycle)
+	}
+}
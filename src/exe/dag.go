@@ -4,7 +4,8 @@ import (
 	"context"
 	"fmt"
 	"regexp"
-	"runtime/debug"
+	"strconv"
+	"time"
 
 	"github.com/SakuraSa/ge/src/concept"
 	"github.com/SakuraSa/ge/src/util/gslice"
@@ -18,70 +19,124 @@ var (
 	ErrUnknownDep = fmt.Errorf("unknown dep in DAG")
 )
 
+// DAG is a task that executes its nodes in dependency order, running every
+// node whose dependencies are satisfied concurrently.
+//
+// Nodes run against a context derived from the caller's one. When a node
+// fails or the caller's context is done, the run is aborted: the context is
+// cancelled, and Do waits for the in-flight nodes to return before reporting,
+// so no node goroutine outlives the run unless the grace period runs out
+// first. With CollectAll, a failure does not abort the run, and Do reports
+// every failure once the rest of the graph is done.
+//
+// Whether a node runs once the nodes it waits for are done is decided by its
+// TriggerRule. By default, a node only runs if all of them succeeded, and is
+// upstream_failed or skipped otherwise, which in turn decides the fate of the
+// nodes waiting for it. Nodes triggered Always still run when a failure
+// aborts the run, which suits cleanup.
+//
+// Start runs a DAG in the background and reports the state of every node,
+// while the run is in progress and once it is over. WithCheckpoint records
+// the nodes that succeed, so that WithResume can skip them when the run is
+// started again.
 type DAG struct {
-	nodes []concept.Task
-	edges [][]int
+	nodes   []concept.Task
+	names   []string
+	options []nodeOptions
+	edges   [][]int
+	mode    FailureMode
+	grace   time.Duration
+	limiter *Limiter
+	store   StateStore
+	runID   string
+	resume  bool
+
+	fingerprints FingerprintStore
+	workflow     string
 }
 
 func (d DAG) Do(ctx context.Context) error {
+	return d.do(ctx, newRunReport(d))
+}
+
+// do runs d, keeping report up to date.
+func (d DAG) do(ctx context.Context, report *runReport) (err error) {
+	emitRun(ctx, RunStarted, ExecutorDAG, nil)
+	defer func() {
+		report.finished(err)
+		emitRun(ctx, RunFinished, ExecutorDAG, err)
+	}()
 	if len(d.nodes) == 0 {
 		return nil
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return newDAGRun(ctx, d, report).execute()
+}
+
+// WithMode returns a copy of d that reacts to failures according to mode.
+// FailFast and WaitAll both abort the run on the first failure.
+func (d DAG) WithMode(mode FailureMode) DAG {
+	d.mode = mode
+	return d
+}
+
+// WithGracePeriod returns a copy of d that waits at most grace for its
+// in-flight nodes to return once the run is aborted. A zero grace period,
+// the default, waits until every node has returned.
+func (d DAG) WithGracePeriod(grace time.Duration) DAG {
+	d.grace = grace
+	return d
+}
+
+// WithLimiter returns a copy of d that runs at most as many nodes at a time as
+// limiter allows; ready nodes beyond that wait in its queue.
+func (d DAG) WithLimiter(limiter *Limiter) DAG {
+	d.limiter = limiter
+	return d
+}
+
+func (DAG) executor() {}
 
-	type Result struct {
-		err   error
-		index int
+// Nodes returns the metadata of the nodes of d: the name they were added
+// with, the description of their task, and the labels of their task and
+// options.
+func (d DAG) Nodes() []concept.Meta {
+	metas := make([]concept.Meta, len(d.nodes))
+	for i := range d.nodes {
+		metas[i] = d.meta(i)
 	}
-	var (
-		closed    = 0
-		conds     = make([]int, 0, len(d.nodes))
-		onReady   = make(chan int, len(d.nodes))
-		onFinnish = make(chan Result, len(d.nodes))
-	)
+	return metas
+}
 
-	conds = d.getConds()
+// meta returns the metadata of the index-th node.
+func (d DAG) meta(index int) concept.Meta {
+	meta := d.option(index).meta(d.nodes[index])
+	meta.Name = d.name(index)
+	return meta
+}
 
-	for i, cond := range conds {
-		if cond == 0 {
-			onReady <- i
-		}
+// name returns the name of the index-th node.
+func (d DAG) name(index int) string {
+	if index < len(d.names) {
+		return d.names[index]
 	}
+	return strconv.Itoa(index)
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case index := <-onReady:
-			go func() {
-				var (
-					err   error
-					child = d.nodes[index]
-					f     = GetAOP(ctx).Apply(child.Do)
-				)
-				defer func() {
-					if e := recover(); e != nil {
-						err = fmt.Errorf("task %s panic: %v\n%s", child, e, debug.Stack())
-					}
-					onFinnish <- Result{err, index}
-				}()
-				err = f(ctx)
-			}()
-		case result := <-onFinnish:
-			if result.err != nil {
-				return result.err
-			}
-			closed++
-			for _, index := range d.edges[result.index] {
-				conds[index]--
-				if conds[index] == 0 {
-					onReady <- index
-				}
-			}
-			if closed == len(d.nodes) {
-				return nil
-			}
-		}
+// option returns the options of the index-th node.
+func (d DAG) option(index int) nodeOptions {
+	if index < len(d.options) {
+		return d.options[index]
 	}
+	return nodeOptions{}
+}
+
+// task returns the task of the index-th node, decorated according to its
+// options.
+func (d DAG) task(index int) concept.Task {
+	return d.option(index).decorate(d.nodes[index])
 }
 
 func (d DAG) getConds() []int {
@@ -94,58 +149,93 @@ func (d DAG) getConds() []int {
 	return conds
 }
 
+// DAGBuilder builds a DAG from named nodes and the edges between them.
+//
+// An edge from a node to another means the second one waits for the first
+// one to succeed. Edges are declared on either end: DependsOn lists the nodes
+// a node waits for, and Before (or its alias Feeds) lists the nodes waiting
+// for it. The deps of AddNode are Before edges, which is easy to misread as
+// DependsOn: AddNode("b", t, "a") runs b before a.
+//
+// An edge declared more than once, on the same end or on both, counts once,
+// so that graphs can move from one kind of edge to the other gradually.
 type DAGBuilder struct {
 	nodeMap map[string]concept.Task
 	edgeMap map[string][]string
+	optsMap map[string]nodeOptions
 }
 
 func NewDAGBuilder() *DAGBuilder {
 	return &DAGBuilder{
 		nodeMap: make(map[string]concept.Task),
 		edgeMap: make(map[string][]string),
+		optsMap: make(map[string]nodeOptions),
 	}
 }
 
+// AddNode adds the node called name, which runs before the nodes matching
+// deps, as with Before. Use AddNodeWithOptions and DependsOn to declare the
+// nodes it runs after instead.
 func (d *DAGBuilder) AddNode(name string, task concept.Task, deps ...string) {
+	d.AddNodeWithOptions(name, task, Before(deps...))
+}
+
+// AddNodeWithOptions adds the node called name, configured by opts.
+func (d *DAGBuilder) AddNodeWithOptions(name string, task concept.Task, opts ...NodeOption) {
+	o := newNodeOptions(opts)
 	d.nodeMap[name] = task
-	d.edgeMap[name] = deps
+	d.edgeMap[name] = o.before
+	d.optsMap[name] = o
 }
 
 func (d *DAGBuilder) Build() (DAG, error) {
 	nodes := make([]concept.Task, 0, len(d.nodeMap))
+	names := make([]string, 0, len(d.nodeMap))
+	options := make([]nodeOptions, 0, len(d.nodeMap))
 	edges := make([][]int, len(d.nodeMap))
 	nodeIndex := make(map[string]int)
 
 	for name := range d.nodeMap {
 		nodeIndex[name] = len(nodes)
 		nodes = append(nodes, d.nodeMap[name])
+		names = append(names, name)
+		options = append(options, d.optsMap[name])
 	}
 
 	for name, deps := range d.edgeMap {
 		index := nodeIndex[name]
 		for _, dep := range deps {
-			depIndex, found := nodeIndex[dep]
-			if found {
-				edges[index] = append(edges[index], depIndex)
-				continue
+			next, err := resolveNodes(nodeIndex, dep)
+			if err != nil {
+				return DAG{}, err
 			}
+			edges[index] = append(edges[index], next...)
+		}
+	}
 
-			reg, regErr := regexp.Compile(dep)
-			if regErr != nil {
-				return DAG{}, regErr
+	for name, o := range d.optsMap {
+		index := nodeIndex[name]
+		for _, dep := range o.after {
+			prev, err := resolveNodes(nodeIndex, dep)
+			if err != nil {
+				return DAG{}, err
 			}
-
-			for depName, depIndex := range nodeIndex {
-				if reg.MatchString(depName) {
-					edges[index] = append(edges[index], depIndex)
-				}
+			for _, prevIndex := range prev {
+				edges[prevIndex] = append(edges[prevIndex], index)
 			}
 		}
 	}
 
+	// the same edge may be declared on both ends, or matched by several deps
+	for i := range edges {
+		edges[i] = gslice.Uniq(edges[i])
+	}
+
 	dag := DAG{
-		nodes: nodes,
-		edges: edges,
+		nodes:   nodes,
+		names:   names,
+		options: options,
+		edges:   edges,
 	}
 
 	for _, f := range DAGCheckers {
@@ -157,20 +247,47 @@ func (d *DAGBuilder) Build() (DAG, error) {
 	return dag, nil
 }
 
+// resolveNodes returns the nodes of index named pattern, or matching it as a
+// regexp.
+func resolveNodes(index map[string]int, pattern string) ([]int, error) {
+	if i, found := index[pattern]; found {
+		return []int{i}, nil
+	}
+
+	reg, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []int
+	for name, i := range index {
+		if reg.MatchString(name) {
+			matches = append(matches, i)
+		}
+	}
+	return matches, nil
+}
+
 // DAGChecker is a function to check the validity of a DAG
 type DAGChecker func(DAG) error
 
 var (
-	DAGCheckers = []DAGChecker{checkCycle, checkDuplicate, checkUnknownDep}
+	DAGCheckers = []DAGChecker{checkCycle, checkDuplicate, checkUnknownDep, checkOutputs}
 )
 
-// checkCycle checks if there is a cycle in the DAG
+// checkCycle checks if there is a cycle in the DAG, and reports it as a
+// *CycleError along with the other elementary cycles of the DAG.
 func checkCycle(d DAG) error {
 	var path []int
 	var visited = make([]int, len(d.nodes))
 	for i := range d.nodes {
 		if visited[i] == 0 {
 			if err := dfs(d, i, &path, visited); err != nil {
+				if cycleErr, ok := err.(*CycleError); ok {
+					for _, cycle := range elementaryCycles(d.edges, MaxReportedCycles) {
+						cycleErr.Cycles = append(cycleErr.Cycles, d.cycleNames(cycle))
+					}
+				}
 				return err
 			}
 		}
@@ -185,6 +302,11 @@ func dfs(d DAG, index int, path *[]int, visited []int) error {
 	*path = append(*path, index)
 	for _, edge := range d.edges[index] {
 		if visited[edge] == 1 {
+			for i, node := range *path {
+				if node == edge {
+					return &CycleError{Path: d.cycleNames((*path)[i:])}
+				}
+			}
 			return ErrCycle
 		}
 		if visited[edge] == 0 {
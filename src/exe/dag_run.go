@@ -0,0 +1,364 @@
+package exe
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+// dagRun is a run of a DAG. Its fields are only used by the goroutine
+// running Do; the node goroutines report through onFinnish.
+type dagRun struct {
+	d       DAG
+	ctx     context.Context // the caller's context
+	base    context.Context // ctx carrying the outputs of the run
+	runCtx  context.Context // base, cancelled when the run is aborted
+	cancel  context.CancelFunc
+	outputs *outputStore
+	report  *runReport
+	saved   *checkpoint
+
+	states    []NodeState
+	upstream  []int
+	counts    []upstreamCounts
+	running   int
+	closed    int
+	aborted   bool
+	errs      []error
+	onFinnish chan dagResult
+}
+
+// dagResult is the outcome of a node.
+type dagResult struct {
+	err      error
+	index    int
+	attempts int
+	// upToDate is true if the node did not run, as its inputs did not
+	// change since its last success.
+	upToDate bool
+	// recordErr is the failure to record the inputs of the node.
+	recordErr error
+}
+
+func newDAGRun(ctx context.Context, d DAG, report *runReport) *dagRun {
+	r := &dagRun{
+		d:        d,
+		ctx:      ctx,
+		outputs:  newOutputStore(ctx, d),
+		report:   report,
+		states:   make([]NodeState, len(d.nodes)),
+		upstream: d.getConds(),
+		counts:   make([]upstreamCounts, len(d.nodes)),
+		// every node is launched at most once, so onFinnish never blocks
+		// the node goroutines, even after Do has returned.
+		onFinnish: make(chan dagResult, len(d.nodes)),
+	}
+	r.base = r.outputs.with(ctx)
+	r.runCtx, r.cancel = context.WithCancel(r.base)
+	return r
+}
+
+// execute runs the nodes and returns the failures reported.
+func (r *dagRun) execute() error {
+	defer r.cancel()
+
+	saved, err := loadCheckpoint(r.ctx, r.d)
+	if err != nil {
+		return err
+	}
+	r.saved = saved
+	r.restore()
+
+	var roots []int
+	for i, n := range r.upstream {
+		if n == 0 && r.states[i] == Pending {
+			roots = append(roots, i)
+		}
+	}
+	r.launchAll(roots)
+
+	var timeout <-chan time.Time
+	for r.closed < len(r.d.nodes) {
+		select {
+		case <-r.ctx.Done():
+			r.drain()
+			return joinErrors(append(r.errs, r.ctx.Err()))
+		case result := <-r.onFinnish:
+			r.finish(result)
+			if r.aborted && timeout == nil && r.d.grace > 0 {
+				timer := time.NewTimer(r.d.grace)
+				defer timer.Stop()
+				timeout = timer.C
+			}
+		case <-timeout:
+			return joinErrors(r.errs)
+		}
+	}
+	return joinErrors(r.errs)
+}
+
+// launch starts the index-th node. Nodes triggered Always run against a
+// context that aborting the run does not cancel.
+func (r *dagRun) launch(index int) {
+	r.states[index] = Running
+	r.running++
+	option := r.d.option(index)
+	ctx := r.runCtx
+	if option.trigger == Always {
+		ctx = r.base
+	}
+	t := r.d.limiter.reserve(option.priority)
+	task := r.d.task(index)
+	info := newTaskInfo(r.ctx, ExecutorDAG, index, r.d.meta(index))
+	emitNode(r.ctx, NodeReady, info, Pending, nil)
+	go func() {
+		r.onFinnish <- r.run(ctx, t, info, task, index)
+	}()
+}
+
+// run runs the index-th node, as the run function does, unless it is up to
+// date, and reports when it starts. The inputs of the node are checked once
+// it holds its slot of the limiter, so that hashing them is bounded too.
+func (r *dagRun) run(ctx context.Context, t *ticket, info concept.TaskInfo, task concept.Task, index int) dagResult {
+	release, err := start(ctx, r.d.limiter, t, task)
+	if err != nil {
+		emitNode(ctx, NodeSkipped, info, Cancelled, err)
+		return dagResult{err: err, index: index}
+	}
+	defer release()
+
+	fingerprint, err := r.fingerprint(ctx, index)
+	if err == nil && fingerprint != "" {
+		var upToDate bool
+		if upToDate, err = r.upToDate(ctx, info, index, fingerprint); upToDate {
+			emitNode(ctx, NodeSkipped, info, Succeeded, nil)
+			return dagResult{index: index, upToDate: true}
+		}
+	}
+	if err != nil {
+		err = newTaskError(info.Path, 0, 0, err)
+		emitNode(ctx, NodeFinished, info, Failed, err)
+		return dagResult{err: err, index: index}
+	}
+
+	r.report.started(index)
+	attempts, err := invoke(ctx, info, task)
+	result := dagResult{err: err, index: index, attempts: attempts}
+	if err == nil && fingerprint != "" {
+		result.recordErr = r.recordSuccess(ctx, info, index, fingerprint)
+	}
+	return result
+}
+
+// launchAll launches the ready nodes, highest priority first.
+func (r *dagRun) launchAll(ready []int) {
+	sort.SliceStable(ready, func(i, j int) bool {
+		return r.d.option(ready[i]).priority > r.d.option(ready[j]).priority
+	})
+	for _, index := range ready {
+		r.launch(index)
+	}
+}
+
+// finish records the outcome of a node.
+func (r *dagRun) finish(result dagResult) {
+	r.running--
+	index := result.index
+	option := r.d.option(index)
+	state, seen := r.outcome(result)
+	if result.upToDate {
+		r.report.upToDate(index)
+	} else {
+		r.report.settled(index, state, result.attempts, result.err)
+	}
+	if state == Succeeded {
+		r.outputs.complete(r.d.name(index))
+		if err := r.checkpoint(index); err != nil {
+			r.errs = append(r.errs, err)
+		}
+	}
+	if result.recordErr != nil {
+		r.errs = append(r.errs, result.recordErr)
+	}
+	if seen == Failed {
+		r.errs = append(r.errs, result.err)
+		if option.onFailure == FailRun && r.d.mode != CollectAll && !r.aborted {
+			r.abort()
+		}
+	}
+	r.settle(index, state, seen)
+}
+
+// outcome returns the final state of a node that returned, and the state its
+// downstream nodes see.
+func (r *dagRun) outcome(result dagResult) (state, seen NodeState) {
+	option := r.d.option(result.index)
+	switch {
+	case result.err == nil:
+		return Succeeded, Succeeded
+	case r.aborted && option.trigger != Always:
+		return Cancelled, Cancelled
+	case option.onFailure == IgnoreFailure:
+		return Failed, Succeeded
+	default:
+		return Failed, Failed
+	}
+}
+
+// settle records the final state of a node, and decides the fate of the
+// nodes waiting for it from the state their trigger rules see.
+func (r *dagRun) settle(index int, state, seen NodeState) {
+	r.states[index] = state
+	r.closed++
+
+	var ready []int
+	for _, next := range r.d.edges[index] {
+		c := &r.counts[next]
+		c.done++
+		switch seen {
+		case Succeeded:
+			c.succeeded++
+		case Skipped:
+			c.skipped++
+		default:
+			c.failed++
+		}
+		if r.states[next] != Pending {
+			continue
+		}
+		switch fate := r.decide(next); fate {
+		case Pending:
+		case Running:
+			// mark it now, so that it is not decided again before launch
+			r.states[next] = Running
+			ready = append(ready, next)
+		default:
+			r.skip(next, fate)
+		}
+	}
+	r.launchAll(ready)
+}
+
+// checkpoint records in the checkpoint of the run that a node succeeded,
+// along with its output. A node whose output cannot be encoded is not
+// recorded, so that it runs again on resume.
+func (r *dagRun) checkpoint(index int) error {
+	if r.saved == nil {
+		return nil
+	}
+	var output json.RawMessage
+	if value, ok := r.outputs.lookup(r.d.name(index)); ok {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil
+		}
+		output = data
+	}
+	return r.saved.record(r.ctx, r.d.name(index), output)
+}
+
+// restore settles the nodes the checkpoint of the run recorded as succeeded,
+// and restores their outputs.
+func (r *dagRun) restore() {
+	var resumed []int
+	for i := range r.d.nodes {
+		if r.saved.succeeded(r.d.name(i)) && r.restoreOutput(i) {
+			// mark them all first, so that none is decided by another
+			r.states[i] = Running
+			resumed = append(resumed, i)
+		}
+	}
+	for _, index := range resumed {
+		r.outputs.complete(r.d.name(index))
+		r.report.resumed(index)
+		r.emitSkipped(index, Succeeded)
+		r.settle(index, Succeeded, Succeeded)
+	}
+}
+
+// skip settles a node that does not run in state.
+func (r *dagRun) skip(index int, state NodeState) {
+	r.report.settled(index, state, 0, nil)
+	r.emitSkipped(index, state)
+	r.settle(index, state, state)
+}
+
+// emitSkipped emits NodeSkipped for a node that does not run.
+func (r *dagRun) emitSkipped(index int, state NodeState) {
+	info := newTaskInfo(r.ctx, ExecutorDAG, index, r.d.meta(index))
+	emitNode(r.ctx, NodeSkipped, info, state, nil)
+}
+
+// decide returns the fate of a pending node, as decide does for its trigger
+// rule. Once the run is aborted, only the nodes triggered Always may run.
+func (r *dagRun) decide(index int) NodeState {
+	rule := r.d.option(index).trigger
+	if r.aborted && rule != Always {
+		return Cancelled
+	}
+	return rule.decide(r.counts[index], r.upstream[index])
+}
+
+// abort cancels the in-flight nodes and the pending ones, except for the
+// nodes triggered Always.
+func (r *dagRun) abort() {
+	r.aborted = true
+	r.cancel()
+	for i := range r.states {
+		if r.states[i] == Pending && r.d.option(i).trigger != Always {
+			r.skip(i, Cancelled)
+		}
+	}
+}
+
+// drain cancels every node and waits for the in-flight ones to return, for at
+// most the grace period of the DAG.
+func (r *dagRun) drain() {
+	r.aborted = true
+	r.cancel()
+	for i := range r.states {
+		if r.states[i] == Pending {
+			r.states[i] = Cancelled
+			r.report.settled(i, Cancelled, 0, nil)
+			r.emitSkipped(i, Cancelled)
+		}
+	}
+
+	var timeout <-chan time.Time
+	if r.d.grace > 0 {
+		timer := time.NewTimer(r.d.grace)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for ; r.running > 0; r.running-- {
+		select {
+		case result := <-r.onFinnish:
+			state, _ := r.outcome(result)
+			r.states[result.index] = state
+			r.report.settled(result.index, state, result.attempts, result.err)
+		case <-timeout:
+			return
+		}
+	}
+}
+
+// restoreOutput restores the output of a node from the checkpoint of the run,
+// and reports false if the node has to run again as it cannot be decoded.
+func (r *dagRun) restoreOutput(index int) bool {
+	output, ok := r.saved.output(r.d.name(index))
+	if !ok {
+		return true
+	}
+	for _, decl := range r.d.option(index).produces {
+		value, err := decl.decode(output)
+		if err != nil {
+			// the output changed type since it was recorded
+			return false
+		}
+		r.outputs.restore(decl.node, value)
+	}
+	return true
+}
@@ -2,7 +2,9 @@ package exe
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -392,4 +394,246 @@ func TestDAG(t *testing.T) {
 			t.Errorf("DAG.Build() error = %v, wantErr %v", err, true)
 		}
 	})
+	t.Run("fail-fast drains in-flight nodes", func(t *testing.T) {
+		var finished bool
+		b := NewDAGBuilder()
+		b.AddNode("error", T(func(ctx context.Context) error {
+			time.Sleep(time.Millisecond * 5)
+			return errTest
+		}))
+		b.AddNode("wait", T(func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(time.Millisecond * 10)
+			finished = true
+			return ctx.Err()
+		}))
+		s, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		err = s.Do(context.Background())
+		if !errors.Is(err, errTest) {
+			t.Errorf("DAG.Do() error = %v, want %v", err, errTest)
+		}
+		if !finished {
+			t.Errorf("DAG.Do() returned before in-flight nodes finished")
+		}
+	})
+
+	t.Run("grace period", func(t *testing.T) {
+		b := NewDAGBuilder()
+		b.AddNode("error", T(func(ctx context.Context) error {
+			return fmt.Errorf("error")
+		}))
+		b.AddNode("stubborn", T(func(ctx context.Context) error {
+			time.Sleep(time.Millisecond * 200)
+			return nil
+		}))
+		s, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		start := time.Now()
+		if err = s.WithGracePeriod(time.Millisecond * 10).Do(context.Background()); err == nil {
+			t.Errorf("DAG.Do() error = %v, wantErr %v", err, true)
+		}
+		if elapsed := time.Since(start); elapsed > time.Millisecond*100 {
+			t.Errorf("DAG.Do() took %v, want it bounded by the grace period", elapsed)
+		}
+	})
+	t.Run("task error attribution", func(t *testing.T) {
+		b := NewDAGBuilder()
+		b.AddNode("ok", T(func(ctx context.Context) error {
+			return nil
+		}))
+		b.AddNode("outer", NewSerial(T(func(ctx context.Context) error {
+			return errTest
+		})))
+		s, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		err = s.Do(context.Background())
+		var te *TaskError
+		if !errors.As(err, &te) {
+			t.Errorf("DAG.Do() error = %v, want a *TaskError", err)
+			return
+		}
+		if strings.Join(te.Path, "/") != "outer/0" || te.Name != "0" || te.Attempt != 1 {
+			t.Errorf("DAG.Do() error = %+v, want it attributed to outer/0", te)
+		}
+		if !errors.Is(err, errTest) {
+			t.Errorf("DAG.Do() error = %v, want it to wrap %v", err, errTest)
+		}
+	})
+
+	t.Run("collect-all", func(t *testing.T) {
+		var ran, blocked bool
+		b := NewDAGBuilder()
+		b.AddNode("error-1", T(func(ctx context.Context) error {
+			return errTest
+		}), "after-error")
+		b.AddNode("after-error", T(func(ctx context.Context) error {
+			blocked = false
+			return nil
+		}))
+		b.AddNode("error-2", T(func(ctx context.Context) error {
+			time.Sleep(time.Millisecond * 5)
+			return fmt.Errorf("error")
+		}))
+		b.AddNode("independent", T(func(ctx context.Context) error {
+			time.Sleep(time.Millisecond * 10)
+			ran = ctx.Err() == nil
+			return nil
+		}))
+		s, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		blocked = true
+		err = s.WithMode(CollectAll).Do(context.Background())
+		var me *MultiError
+		if !errors.As(err, &me) || len(me.Errors) != 2 {
+			t.Errorf("DAG.Do() error = %v, want 2 collected errors", err)
+		}
+		if !ran {
+			t.Errorf("DAG.Do() did not run independent nodes in collect-all mode")
+		}
+		if !blocked {
+			t.Errorf("DAG.Do() ran a node downstream of a failed one")
+		}
+	})
+	t.Run("nodes metadata", func(t *testing.T) {
+		b := NewDAGBuilder()
+		b.AddNode("db", concept.WithMeta(T(func(ctx context.Context) error {
+			return nil
+		}), concept.Meta{
+			Name:        "ignored",
+			Description: "query the database",
+			Labels:      map[string]string{"kind": "db"},
+		}))
+		s, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		nodes := s.Nodes()
+		if len(nodes) != 1 || nodes[0].Name != "db" || nodes[0].Description != "query the database" || nodes[0].Labels["kind"] != "db" {
+			t.Errorf("DAG.Nodes() = %+v", nodes)
+		}
+	})
+	t.Run("node timeout", func(t *testing.T) {
+		b := NewDAGBuilder()
+		b.AddNodeWithOptions("slow", T(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}), WithTimeout(time.Millisecond))
+		s, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		if err = s.Do(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("DAG.Do() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+	t.Run("dependency direction", func(t *testing.T) {
+		appender := func(value string) concept.Task {
+			return T(func(ctx context.Context) error {
+				v := ctx.Value(testKey).(*TestValue)
+				v.Values = append(v.Values, value)
+				return nil
+			})
+		}
+		tests := []struct {
+			name  string
+			nodes map[string][]NodeOption
+			want  string
+		}{
+			{
+				name: "depends on",
+				nodes: map[string][]NodeOption{
+					"1": nil,
+					"2": {DependsOn("1")},
+					"3": {DependsOn("2")},
+					"4": {DependsOn("1", "3")},
+				},
+				want: "1,2,3,4",
+			},
+			{
+				name: "before",
+				nodes: map[string][]NodeOption{
+					"1": nil,
+					"2": {Before("1")},
+					"3": {Before("2")},
+					"4": {Before("1", "3")},
+				},
+				want: "4,3,2,1",
+			},
+			{
+				name: "mixed",
+				nodes: map[string][]NodeOption{
+					"1": {Feeds("2")},
+					"2": nil,
+					"3": {DependsOn("2"), Before("4")},
+					"4": nil,
+				},
+				want: "1,2,3,4",
+			},
+			{
+				name: "same edge on both ends",
+				nodes: map[string][]NodeOption{
+					"1": {Before("2")},
+					"2": {DependsOn("1"), DependsOn("1")},
+					"3": {DependsOn("[12]", "2")},
+				},
+				want: "1,2,3",
+			},
+			{
+				name: "wildcard",
+				nodes: map[string][]NodeOption{
+					"task-1": nil,
+					"task-2": {DependsOn("task-1")},
+					"task-3": {DependsOn("task-2")},
+					"last":   {DependsOn("task-[0-9]")},
+				},
+				want: "task-1,task-2,task-3,last",
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				b := NewDAGBuilder()
+				for name, opts := range tt.nodes {
+					b.AddNodeWithOptions(name, appender(name), opts...)
+				}
+				s, err := b.Build()
+				if err != nil {
+					t.Errorf("DAG.Build() error = %v", err)
+					return
+				}
+				v := &TestValue{}
+				if err = s.Do(context.WithValue(context.Background(), testKey, v)); err != nil {
+					t.Errorf("DAG.Do() error = %v", err)
+				}
+				if v.String() != tt.want {
+					t.Errorf("DAG.Do() ran %v, want %v", v.String(), tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("build err:bad depends on wildcard", func(t *testing.T) {
+		b := NewDAGBuilder()
+		b.AddNodeWithOptions("1", T(func(ctx context.Context) error {
+			return nil
+		}), DependsOn("("))
+		_, err := b.Build()
+		if err == nil {
+			t.Errorf("DAG.Build() error = %v, wantErr %v", err, true)
+		}
+	})
 }
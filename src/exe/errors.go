@@ -0,0 +1,154 @@
+package exe
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	_ error = (*TaskError)(nil)
+	_ error = (*MultiError)(nil)
+	_ error = (*PanicError)(nil)
+)
+
+// TaskError is the error reported by an executor when one of its tasks fails.
+type TaskError struct {
+	// Name is the name of the task within its executor.
+	Name string
+	// Path holds the names of the enclosing tasks, outermost first, and ends
+	// with Name.
+	Path []string
+	// Attempt is the attempt the task failed on, starting at 1.
+	Attempt int
+	// Duration is how long the task ran before failing.
+	Duration time.Duration
+	// Err is the error returned by the task.
+	Err error
+}
+
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("task %s: %v", strings.Join(e.Path, "/"), e.Err)
+}
+
+// Unwrap returns the error returned by the task.
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError is the error reported by an executor when several of its tasks
+// fail.
+type MultiError struct {
+	Errors []error
+}
+
+func (e *MultiError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors occurred: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the collected errors.
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
+// Is reports whether any of the collected errors matches target.
+func (e *MultiError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first collected error that matches target.
+func (e *MultiError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// PanicError is the error reported when a task panics.
+type PanicError struct {
+	// Name is the name of the task within its executor.
+	Name string
+	// Path holds the names of the enclosing tasks, outermost first, and ends
+	// with Name.
+	Path []string
+	// Value is the value the task panicked with.
+	Value interface{}
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// joinErrors returns nil for no errors, the error itself for a single one and
+// a *MultiError otherwise.
+func joinErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return &MultiError{Errors: errs}
+	}
+}
+
+// newTaskError attributes err to the task at path, unless a nested executor
+// already did.
+func newTaskError(path []string, attempt int, duration time.Duration, err error) error {
+	if attributed(err, path) {
+		return err
+	}
+	return &TaskError{
+		Name:     path[len(path)-1],
+		Path:     path,
+		Attempt:  attempt,
+		Duration: duration,
+		Err:      err,
+	}
+}
+
+// attributed reports whether err only holds TaskErrors of tasks nested in
+// the task at path.
+func attributed(err error, path []string) bool {
+	switch e := err.(type) {
+	case *TaskError:
+		if len(e.Path) <= len(path) {
+			return false
+		}
+		for i := range path {
+			if e.Path[i] != path[i] {
+				return false
+			}
+		}
+		return true
+	case *MultiError:
+		for _, err := range e.Errors {
+			if !attributed(err, path) {
+				return false
+			}
+		}
+		return len(e.Errors) > 0
+	default:
+		return false
+	}
+}
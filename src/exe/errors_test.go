@@ -0,0 +1,147 @@
+package exe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+func TestMultiError(t *testing.T) {
+	other := errors.New("other")
+	te := &TaskError{Name: "b", Path: []string{"a", "b"}, Attempt: 1, Err: errTest}
+	err := joinErrors([]error{other, fmt.Errorf("wrapped: %w", te)})
+
+	var me *MultiError
+	if !errors.As(err, &me) || len(me.Errors) != 2 {
+		t.Errorf("joinErrors() = %v, want a *MultiError of 2 errors", err)
+	}
+	if !errors.Is(err, errTest) || !errors.Is(err, other) {
+		t.Errorf("errors.Is() = false through %v", err)
+	}
+	var got *TaskError
+	if !errors.As(err, &got) || got != te {
+		t.Errorf("errors.As() = %v, want %v", got, te)
+	}
+	if errors.Is(err, errors.New("test error")) {
+		t.Errorf("errors.Is() matched an unrelated error")
+	}
+	if joinErrors(nil) != nil {
+		t.Errorf("joinErrors(nil) != nil")
+	}
+	if joinErrors([]error{other}) != other {
+		t.Errorf("joinErrors() wrapped a single error")
+	}
+}
+
+func Test_newTaskError(t *testing.T) {
+	nested := &TaskError{Name: "c", Path: []string{"a", "b", "c"}, Err: errTest}
+	tests := []struct {
+		name    string
+		err     error
+		path    []string
+		wantNew bool
+	}{
+		{
+			name:    "plain",
+			err:     errTest,
+			path:    []string{"a"},
+			wantNew: true,
+		},
+		{
+			name:    "nested",
+			err:     nested,
+			path:    []string{"a", "b"},
+			wantNew: false,
+		},
+		{
+			name:    "nested multi",
+			err:     &MultiError{Errors: []error{nested, nested}},
+			path:    []string{"a"},
+			wantNew: false,
+		},
+		{
+			name:    "unrelated",
+			err:     nested,
+			path:    []string{"x"},
+			wantNew: true,
+		},
+		{
+			name:    "same task",
+			err:     nested,
+			path:    []string{"a", "b", "c"},
+			wantNew: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newTaskError(tt.path, 1, 0, tt.err)
+			if gotNew := err != tt.err; gotNew != tt.wantNew {
+				t.Errorf("newTaskError() = %v, wantNew %v", err, tt.wantNew)
+			}
+		})
+	}
+}
+
+func TestPanicError(t *testing.T) {
+	panicky := T(func(ctx context.Context) error {
+		panic(errTest)
+	})
+	b := NewDAGBuilder()
+	b.AddNode("panic", panicky)
+	dag, err := b.Build()
+	if err != nil {
+		t.Errorf("DAG.Build() error = %v", err)
+		return
+	}
+	tests := []struct {
+		name     string
+		task     concept.Task
+		wantPath string
+	}{
+		{
+			name:     "serial",
+			task:     NewSerial(panicky),
+			wantPath: "0",
+		},
+		{
+			name:     "parallel",
+			task:     NewParallel(panicky),
+			wantPath: "0",
+		},
+		{
+			name:     "dag",
+			task:     dag,
+			wantPath: "panic",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.task.Do(context.Background())
+			var pe *PanicError
+			if !errors.As(err, &pe) {
+				t.Errorf("Do() error = %v, want a *PanicError", err)
+				return
+			}
+			if pe.Value != errTest || strings.Join(pe.Path, "/") != tt.wantPath || len(pe.Stack) == 0 {
+				t.Errorf("Do() error = %+v, want a panic of %v at %v", pe, errTest, tt.wantPath)
+			}
+			if !errors.Is(err, errTest) {
+				t.Errorf("Do() error = %v, want it to wrap the panic value", err)
+			}
+		})
+	}
+
+	t.Run("re-panic", func(t *testing.T) {
+		defer func() {
+			if e := recover(); e != errTest {
+				t.Errorf("recover() = %v, want %v", e, errTest)
+			}
+		}()
+		_ = NewSerial(panicky).Do(SetPanicPolicy(context.Background(), RePanic))
+		t.Errorf("Serial.Do() recovered the panic")
+	})
+}
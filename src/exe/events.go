@@ -0,0 +1,213 @@
+package exe
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+// EventType is the kind of an Event.
+type EventType int
+
+const (
+	// RunStarted is emitted when an executor starts running its children.
+	RunStarted EventType = iota
+	// NodeReady is emitted when an executor schedules a child, which may
+	// then wait for a slot of a limiter.
+	NodeReady
+	// NodeStarted is emitted when a child starts running.
+	NodeStarted
+	// NodeFinished is emitted when a child returns, after its last attempt.
+	NodeFinished
+	// NodeRetried is emitted by Retry before every attempt but the first.
+	NodeRetried
+	// NodeSkipped is emitted for the children an executor does not run.
+	NodeSkipped
+	// RunFinished is emitted when an executor returns.
+	RunFinished
+)
+
+// String returns the name of the type.
+func (t EventType) String() string {
+	switch t {
+	case RunStarted:
+		return "run_started"
+	case NodeReady:
+		return "node_ready"
+	case NodeStarted:
+		return "node_started"
+	case NodeFinished:
+		return "node_finished"
+	case NodeRetried:
+		return "node_retried"
+	case NodeSkipped:
+		return "node_skipped"
+	case RunFinished:
+		return "run_finished"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes a step of the lifecycle of an executor or of one of its
+// children.
+type Event struct {
+	Type EventType
+	Time time.Time
+	// Executor is the kind of executor emitting the event, such as
+	// ExecutorDAG.
+	Executor string
+	// Path is the path of the executor for the run events, and the path of
+	// the child for the node events.
+	Path []string
+	// Node and Index are the name and the index of the child; they are
+	// unset for the run events.
+	Node  string
+	Index int
+	// Attempt is the attempt of the child that finished, or that is about
+	// to start for NodeRetried.
+	Attempt int
+	// State is the state of the child that finished or was skipped.
+	State NodeState
+	// Err is the error of the executor for RunFinished, and the error of
+	// the child, or of its previous attempt for NodeRetried, otherwise.
+	Err error
+}
+
+// Listener receives events.
+type Listener interface {
+	OnEvent(e Event)
+}
+
+// ListenerFunc is a function type that implements Listener.
+type ListenerFunc func(e Event)
+
+// OnEvent calls f(e).
+func (f ListenerFunc) OnEvent(e Event) {
+	f(e)
+}
+
+// Listeners is a list of Listener that implements Listener.
+type Listeners []Listener
+
+// OnEvent hands e to every listener in the list, in order.
+func (l Listeners) OnEvent(e Event) {
+	for _, listener := range l {
+		listener.OnEvent(e)
+	}
+}
+
+// flush waits for every listener in the list to have handled the events it
+// was handed.
+func (l Listeners) flush() {
+	for _, listener := range l {
+		if a, ok := listener.(*asyncListener); ok {
+			a.flush()
+		}
+	}
+}
+
+// asyncListener hands events to a listener on a goroutine of its own, in
+// order, so that a slow listener does not hold executors up. The goroutine
+// only runs while events are pending.
+type asyncListener struct {
+	mu       sync.Mutex
+	idle     *sync.Cond
+	listener Listener
+	queue    []Event
+	running  bool
+}
+
+func newAsyncListener(listener Listener) *asyncListener {
+	a := &asyncListener{listener: listener}
+	a.idle = sync.NewCond(&a.mu)
+	return a
+}
+
+func (a *asyncListener) OnEvent(e Event) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.queue = append(a.queue, e)
+	if !a.running {
+		a.running = true
+		go a.deliver()
+	}
+}
+
+// deliver hands the pending events to the listener.
+func (a *asyncListener) deliver() {
+	for {
+		a.mu.Lock()
+		if len(a.queue) == 0 {
+			a.running = false
+			a.queue = nil
+			a.idle.Broadcast()
+			a.mu.Unlock()
+			return
+		}
+		e := a.queue[0]
+		a.queue[0] = Event{}
+		a.queue = a.queue[1:]
+		a.mu.Unlock()
+		a.listener.OnEvent(e)
+	}
+}
+
+// flush waits for the pending events to be handed to the listener.
+func (a *asyncListener) flush() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for a.running {
+		a.idle.Wait()
+	}
+}
+
+// emit hands e to the Listener in ctx.
+func emit(ctx context.Context, e Event) {
+	listener := GetListener(ctx)
+	if len(listener) == 0 {
+		return
+	}
+	e.Time = time.Now()
+	listener.OnEvent(e)
+}
+
+// emitRun emits a run event of the executor of the given kind, running in
+// ctx. The RunFinished event of an executor that is not run by another one
+// waits for the listeners to handle every event of the run, so that none
+// arrives once the executor returned.
+func emitRun(ctx context.Context, typ EventType, kind string, err error) {
+	info, _ := GetTaskInfo(ctx)
+	emit(ctx, Event{Type: typ, Executor: kind, Path: info.Path, Err: err})
+	if typ == RunFinished && len(info.Path) == 0 {
+		GetListener(ctx).flush()
+	}
+}
+
+// emitNode emits a node event of the child described by info.
+func emitNode(ctx context.Context, typ EventType, info concept.TaskInfo, state NodeState, err error) {
+	emit(ctx, Event{
+		Type:     typ,
+		Executor: info.Executor,
+		Path:     info.Path,
+		Node:     info.Name,
+		Index:    info.Index,
+		Attempt:  info.Attempt,
+		State:    state,
+		Err:      err,
+	})
+}
+
+// finishedState returns the state of a child that returned err in ctx.
+func finishedState(ctx context.Context, err error) NodeState {
+	switch {
+	case err == nil:
+		return Succeeded
+	case ctx.Err() != nil:
+		return Cancelled
+	default:
+		return Failed
+	}
+}
@@ -0,0 +1,143 @@
+package exe
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+// recorder collects events.
+type recorder struct {
+	events []Event
+}
+
+func (r *recorder) OnEvent(e Event) {
+	r.events = append(r.events, e)
+}
+
+// lines returns the events in the form "type node state".
+func (r *recorder) lines() []string {
+	got := make([]string, len(r.events))
+	for i, e := range r.events {
+		got[i] = strings.TrimSpace(e.Type.String() + " " + e.Node)
+		if e.Type == NodeFinished || e.Type == NodeSkipped {
+			got[i] += " " + e.State.String()
+		}
+	}
+	return got
+}
+
+func TestEvents(t *testing.T) {
+	t.Run("dag", func(t *testing.T) {
+		rec := &recorder{}
+		ctx := AddListener(context.Background(), rec)
+		flaky := 0
+		b := NewDAGBuilder()
+		b.AddNodeWithOptions("flaky", T(func(ctx context.Context) error {
+			if flaky++; flaky == 1 {
+				return errTest
+			}
+			return nil
+		}), Before("notify"), WithRetry(NewRetry()))
+		b.AddNodeWithOptions("notify", T(func(ctx context.Context) error {
+			return nil
+		}), WithTrigger(OneFailed))
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		if err := d.Do(ctx); err != nil {
+			t.Errorf("DAG.Do() error = %v", err)
+		}
+		want := []string{
+			"run_started",
+			"node_ready flaky",
+			"node_started flaky",
+			"node_retried flaky",
+			"node_finished flaky succeeded",
+			"node_skipped notify skipped",
+			"run_finished",
+		}
+		if got := rec.lines(); strings.Join(got, ", ") != strings.Join(want, ", ") {
+			t.Errorf("events = %v, want %v", got, want)
+		}
+		if e := rec.events[3]; e.Attempt != 2 || !errors.Is(e.Err, errTest) {
+			t.Errorf("%v event = %+v", e.Type, e)
+		}
+		if e := rec.events[4]; e.Executor != ExecutorDAG || e.Attempt != 2 || len(e.Path) != 1 || e.Path[0] != "flaky" {
+			t.Errorf("%v event = %+v", e.Type, e)
+		}
+	})
+
+	t.Run("serial", func(t *testing.T) {
+		rec := &recorder{}
+		ctx := AddListener(context.Background(), rec)
+		s := NewSerial(
+			concept.WithName(T(func(ctx context.Context) error { return errTest }), "a"),
+			concept.WithName(T(func(ctx context.Context) error { return nil }), "b"),
+		)
+		if err := s.Do(ctx); !errors.Is(err, errTest) {
+			t.Errorf("Serial.Do() error = %v, want %v", err, errTest)
+		}
+		want := []string{
+			"run_started",
+			"node_ready a",
+			"node_started a",
+			"node_finished a failed",
+			"node_skipped b skipped",
+			"run_finished",
+		}
+		if got := rec.lines(); strings.Join(got, ", ") != strings.Join(want, ", ") {
+			t.Errorf("events = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("slow listeners", func(t *testing.T) {
+		release := make(chan struct{})
+		rec := &recorder{}
+		ctx := AddListener(context.Background(), ListenerFunc(func(Event) {
+			<-release
+		}))
+		ctx = AddListener(ctx, rec)
+		var (
+			wg  sync.WaitGroup
+			ran = make(chan struct{})
+		)
+		wg.Add(2)
+		go func() {
+			wg.Wait()
+			close(ran)
+		}()
+		p := NewParallel(
+			T(func(ctx context.Context) error { wg.Done(); return nil }),
+			T(func(ctx context.Context) error { wg.Done(); return nil }),
+		)
+		done := make(chan error)
+		go func() {
+			done <- p.Do(ctx)
+		}()
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Errorf("Parallel children are held up by a listener")
+		}
+		select {
+		case <-done:
+			t.Errorf("Parallel.Do() returned before its events were handled")
+		case <-time.After(time.Millisecond * 10):
+		}
+		close(release)
+		if err := <-done; err != nil {
+			t.Errorf("Parallel.Do() error = %v", err)
+		}
+		if got := rec.lines(); len(got) != 8 {
+			t.Errorf("events = %v, want 8 of them", got)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package exe
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// stateColors are the colors of the nodes in each state in the exported
+// graphs.
+var stateColors = map[NodeState]string{
+	Pending:        "#f5f5f5",
+	Running:        "#87cefa",
+	Succeeded:      "#90ee90",
+	Failed:         "#f08080",
+	Skipped:        "#d3d3d3",
+	UpstreamFailed: "#ffa07a",
+	Cancelled:      "#ffe4b5",
+}
+
+// WriteDOT writes d to w as a Graphviz digraph, whose edges go from every
+// node to the ones waiting for it. If report is not nil, the nodes are
+// colored after their state in it.
+func (d DAG) WriteDOT(w io.Writer, report *RunReport) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph {")
+	fmt.Fprintln(bw, "\trankdir=LR;")
+	fmt.Fprintln(bw, "\tnode [shape=box];")
+	order := d.sorted()
+	for _, i := range order {
+		lines := d.exportLines(i, report)
+		fmt.Fprintf(bw, "\t%s [label=%s", dotQuote(d.name(i)), dotQuote(strings.Join(lines, "\n")))
+		if state, ok := exportState(d.name(i), report); ok {
+			fmt.Fprintf(bw, ", style=filled, fillcolor=%s", dotQuote(stateColors[state]))
+		}
+		fmt.Fprintln(bw, "];")
+	}
+	for _, i := range order {
+		for _, next := range d.sortedEdges(i) {
+			fmt.Fprintf(bw, "\t%s -> %s;\n", dotQuote(d.name(i)), dotQuote(d.name(next)))
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// WriteMermaid writes d to w as a Mermaid flowchart, whose edges go from
+// every node to the ones waiting for it. If report is not nil, the nodes are
+// colored after their state in it.
+func (d DAG) WriteMermaid(w io.Writer, report *RunReport) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "flowchart LR")
+	order := d.sorted()
+	ids := make(map[int]string, len(order))
+	for n, i := range order {
+		ids[i] = fmt.Sprintf("n%d", n)
+	}
+	classes := make(map[NodeState][]string)
+	for _, i := range order {
+		lines := d.exportLines(i, report)
+		for j, line := range lines {
+			lines[j] = mermaidEscape(line)
+		}
+		fmt.Fprintf(bw, "\t%s[\"%s\"]\n", ids[i], strings.Join(lines, "<br/>"))
+		if state, ok := exportState(d.name(i), report); ok {
+			classes[state] = append(classes[state], ids[i])
+		}
+	}
+	for _, i := range order {
+		for _, next := range d.sortedEdges(i) {
+			fmt.Fprintf(bw, "\t%s --> %s\n", ids[i], ids[next])
+		}
+	}
+	for state := Pending; state <= Cancelled; state++ {
+		if nodes, ok := classes[state]; ok {
+			fmt.Fprintf(bw, "\tclassDef %s fill:%s\n", state, stateColors[state])
+			fmt.Fprintf(bw, "\tclass %s %s\n", strings.Join(nodes, ","), state)
+		}
+	}
+	return bw.Flush()
+}
+
+// sorted returns the indices of the nodes of d sorted by name, so that
+// exports do not depend on the order Build gave them.
+func (d DAG) sorted() []int {
+	order := make([]int, len(d.nodes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return d.name(order[i]) < d.name(order[j])
+	})
+	return order
+}
+
+// sortedEdges returns the nodes waiting for the index-th one, sorted by name.
+func (d DAG) sortedEdges(index int) []int {
+	next := append([]int(nil), d.edges[index]...)
+	sort.Slice(next, func(i, j int) bool {
+		return d.name(next[i]) < d.name(next[j])
+	})
+	return next
+}
+
+// exportLines returns the lines of the label of the index-th node: its name,
+// its labels, and its state in report.
+func (d DAG) exportLines(index int, report *RunReport) []string {
+	lines := []string{d.name(index)}
+	labels := d.meta(index).Labels
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, k+"="+labels[k])
+	}
+	if state, ok := exportState(d.name(index), report); ok {
+		lines = append(lines, state.String())
+	}
+	return lines
+}
+
+// exportState returns the state of the node called name in report.
+func exportState(name string, report *RunReport) (NodeState, bool) {
+	if report == nil {
+		return Pending, false
+	}
+	node, ok := report.Node(name)
+	return node.State, ok
+}
+
+// dotQuote returns s as a DOT string.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// mermaidEscape escapes s for a quoted Mermaid label.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
@@ -0,0 +1,118 @@
+package exe
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func exportDAG(t *testing.T) DAG {
+	noop := T(func(ctx context.Context) error { return nil })
+	b := NewDAGBuilder()
+	b.AddNodeWithOptions("load", noop, DependsOn("extract"), WithLabels(map[string]string{"team": `"data"`}))
+	b.AddNode("extract", noop)
+	b.AddNodeWithOptions("notify", noop, DependsOn("load"))
+	d, err := b.Build()
+	if err != nil {
+		t.Fatalf("DAG.Build() error = %v", err)
+	}
+	return d
+}
+
+func TestDAG_WriteDOT(t *testing.T) {
+	d := exportDAG(t)
+	report := RunReport{Nodes: []NodeReport{{Name: "extract", State: Succeeded}, {Name: "load", State: Failed}}}
+	tests := []struct {
+		name   string
+		report *RunReport
+		want   string
+	}{
+		{
+			name: "plain",
+			want: `digraph {
+	rankdir=LR;
+	node [shape=box];
+	"extract" [label="extract"];
+	"load" [label="load\nteam=\"data\""];
+	"notify" [label="notify"];
+	"extract" -> "load";
+	"load" -> "notify";
+}
+`,
+		},
+		{
+			name:   "states",
+			report: &report,
+			want: `digraph {
+	rankdir=LR;
+	node [shape=box];
+	"extract" [label="extract\nsucceeded", style=filled, fillcolor="#90ee90"];
+	"load" [label="load\nteam=\"data\"\nfailed", style=filled, fillcolor="#f08080"];
+	"notify" [label="notify"];
+	"extract" -> "load";
+	"load" -> "notify";
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := d.WriteDOT(&sb, tt.report); err != nil {
+				t.Errorf("DAG.WriteDOT() error = %v", err)
+			}
+			if got := sb.String(); got != tt.want {
+				t.Errorf("DAG.WriteDOT() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDAG_WriteMermaid(t *testing.T) {
+	d := exportDAG(t)
+	report := RunReport{Nodes: []NodeReport{{Name: "extract", State: Succeeded}, {Name: "load", State: Running}, {Name: "notify"}}}
+	tests := []struct {
+		name   string
+		report *RunReport
+		want   string
+	}{
+		{
+			name: "plain",
+			want: `flowchart LR
+	n0["extract"]
+	n1["load<br/>team=#quot;data#quot;"]
+	n2["notify"]
+	n0 --> n1
+	n1 --> n2
+`,
+		},
+		{
+			name:   "states",
+			report: &report,
+			want: `flowchart LR
+	n0["extract<br/>succeeded"]
+	n1["load<br/>team=#quot;data#quot;<br/>running"]
+	n2["notify<br/>pending"]
+	n0 --> n1
+	n1 --> n2
+	classDef pending fill:#f5f5f5
+	class n2 pending
+	classDef running fill:#87cefa
+	class n1 running
+	classDef succeeded fill:#90ee90
+	class n0 succeeded
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := d.WriteMermaid(&sb, tt.report); err != nil {
+				t.Errorf("DAG.WriteMermaid() error = %v", err)
+			}
+			if got := sb.String(); got != tt.want {
+				t.Errorf("DAG.WriteMermaid() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
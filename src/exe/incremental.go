@@ -0,0 +1,264 @@
+package exe
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+// Input is something a DAG node depends on besides the nodes it waits for,
+// such as a file or a parameter. A node declaring inputs with WithInputs is
+// up to date when they have not changed since it last succeeded, and is then
+// not run again by a DAG with a FingerprintStore.
+type Input interface {
+	// Fingerprint returns a digest of the current value of the input.
+	Fingerprint(ctx context.Context) (string, error)
+}
+
+// InputFunc is a function type that implements Input.
+type InputFunc func(ctx context.Context) (string, error)
+
+// Fingerprint calls f(ctx).
+func (f InputFunc) Fingerprint(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// FileInput is the content of the files matching patterns, as understood by
+// filepath.Glob. A pattern matching no file is an error.
+func FileInput(patterns ...string) Input {
+	return InputFunc(func(ctx context.Context) (string, error) {
+		var lines []string
+		for _, pattern := range patterns {
+			paths, err := filepath.Glob(pattern)
+			if err != nil {
+				return "", err
+			}
+			if len(paths) == 0 {
+				return "", fmt.Errorf("no file matches %s", pattern)
+			}
+			sort.Strings(paths)
+			for _, path := range paths {
+				sum, err := hashFile(path)
+				if err != nil {
+					return "", err
+				}
+				lines = append(lines, "file "+path+" "+sum)
+			}
+		}
+		return strings.Join(lines, "\n"), nil
+	})
+}
+
+// ParamInput is a parameter called name, whose value is encoded in JSON.
+func ParamInput(name string, value interface{}) Input {
+	return InputFunc(func(ctx context.Context) (string, error) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("param %s: %w", name, err)
+		}
+		return "param " + name + " " + hashBytes(data), nil
+	})
+}
+
+// OutputInput is the output of the node called node, which must run before
+// the node declaring it. Its value is encoded in JSON.
+func OutputInput(node string) Input {
+	return InputFunc(func(ctx context.Context) (string, error) {
+		value, err := getOutputs(ctx).get(node)
+		if err != nil {
+			return "", err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("output %s: %w", node, err)
+		}
+		return "output " + node + " " + hashBytes(data), nil
+	})
+}
+
+// WithInputs declares the inputs of the node. Inputs only decide whether the
+// node is up to date: a node reading the output of another one should also
+// declare it with OutputInput.
+func WithInputs(inputs ...Input) NodeOption {
+	return func(o *nodeOptions) {
+		o.inputs = append(o.inputs, inputs...)
+	}
+}
+
+// FingerprintRecord is what a FingerprintStore records about the last
+// success of a node.
+type FingerprintRecord struct {
+	// Fingerprint is the digest of the inputs of the node.
+	Fingerprint string `json:"fingerprint"`
+	// Output is the output of the node, encoded in JSON, if it has one.
+	Output json.RawMessage `json:"output,omitempty"`
+	Time   time.Time       `json:"time"`
+}
+
+// FingerprintStore records the inputs of the nodes that succeeded, by the
+// workflow of the DAG and the path of the node.
+type FingerprintStore interface {
+	// Get returns the record of the node at path, and false if there is
+	// none.
+	Get(ctx context.Context, path string) (FingerprintRecord, bool, error)
+	// Put records the last success of the node at path.
+	Put(ctx context.Context, path string, record FingerprintRecord) error
+}
+
+// FileFingerprintStore is a FingerprintStore keeping the record of every node
+// in a JSON file of its directory.
+type FileFingerprintStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+var _ FingerprintStore = (*FileFingerprintStore)(nil)
+
+// NewFileFingerprintStore returns a FileFingerprintStore keeping its files in
+// dir, which is created when needed.
+func NewFileFingerprintStore(dir string) *FileFingerprintStore {
+	return &FileFingerprintStore{dir: dir}
+}
+
+func (s *FileFingerprintStore) Get(_ context.Context, path string) (FingerprintRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.path(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return FingerprintRecord{}, false, nil
+	}
+	if err != nil {
+		return FingerprintRecord{}, false, err
+	}
+	var record FingerprintRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return FingerprintRecord{}, false, fmt.Errorf("fingerprint of %s: %w", path, err)
+	}
+	return record, true, nil
+}
+
+func (s *FileFingerprintStore) Put(_ context.Context, path string, record FingerprintRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeFile(s.path(path), data)
+}
+
+// path returns the file of the node at path.
+func (s *FileFingerprintStore) path(path string) string {
+	return filepath.Join(s.dir, url.PathEscape(path)+".json")
+}
+
+// WithFingerprintStore returns a copy of d that does not run again the nodes
+// whose inputs have not changed since store recorded their last success.
+// Their outputs are restored from store instead, so they must be encoded in
+// JSON; a node whose output cannot be is always run. Workflow identifies d in
+// store, so that DAGs sharing a store do not skip each other's nodes.
+func (d DAG) WithFingerprintStore(store FingerprintStore, workflow string) DAG {
+	d.fingerprints = store
+	d.workflow = workflow
+	return d
+}
+
+// fingerprint returns the digest of the inputs of the index-th node, or an
+// empty string if it is not checked for being up to date.
+func (r *dagRun) fingerprint(ctx context.Context, index int) (string, error) {
+	inputs := r.d.option(index).inputs
+	if r.d.fingerprints == nil || len(inputs) == 0 {
+		return "", nil
+	}
+	lines := []string{r.d.name(index)}
+	for _, input := range inputs {
+		fingerprint, err := input.Fingerprint(ctx)
+		if err != nil {
+			return "", fmt.Errorf("inputs: %w", err)
+		}
+		lines = append(lines, fingerprint)
+	}
+	return hashBytes([]byte(strings.Join(lines, "\n"))), nil
+}
+
+// upToDate reports whether the node described by info last succeeded with
+// the given fingerprint, and if so restores its output.
+func (r *dagRun) upToDate(ctx context.Context, info concept.TaskInfo, index int, fingerprint string) (bool, error) {
+	record, ok, err := r.d.fingerprints.Get(ctx, r.fingerprintKey(info))
+	if err != nil || !ok || record.Fingerprint != fingerprint {
+		return false, err
+	}
+	for _, decl := range r.d.option(index).produces {
+		if record.Output == nil {
+			break
+		}
+		value, err := decl.decode(record.Output)
+		if err != nil {
+			// the output changed type: the record is stale
+			return false, nil
+		}
+		r.outputs.restore(decl.node, value)
+	}
+	return true, nil
+}
+
+// recordSuccess records the fingerprint and the output of the node described
+// by info, which succeeded.
+func (r *dagRun) recordSuccess(ctx context.Context, info concept.TaskInfo, index int, fingerprint string) error {
+	record := FingerprintRecord{Fingerprint: fingerprint, Time: time.Now()}
+	if value, ok := r.outputs.lookup(r.d.name(index)); ok {
+		data, err := json.Marshal(value)
+		if err != nil {
+			// without its output, the node cannot be skipped
+			return nil
+		}
+		record.Output = data
+	}
+	if err := r.d.fingerprints.Put(ctx, r.fingerprintKey(info), record); err != nil {
+		return fmt.Errorf("fingerprint of %s: %w", r.d.name(index), err)
+	}
+	return nil
+}
+
+// fingerprintKey returns the key of the node described by info in the
+// FingerprintStore: its path, after the workflow of the DAG if it has one.
+func (r *dagRun) fingerprintKey(info concept.TaskInfo) string {
+	key := strings.Join(info.Path, "/")
+	if r.d.workflow != "" {
+		key = r.d.workflow + ":" + key
+	}
+	return key
+}
+
+// hashFile returns the digest of the content of the file at path.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashBytes returns the digest of data.
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,171 @@
+package exe
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+func TestDAG_WithFingerprintStore(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		source = filepath.Join(dir, "source.txt")
+		store  = NewFileFingerprintStore(filepath.Join(dir, "cache"))
+		ran    map[string]int
+		got    string
+		read   int
+		param  = "v1"
+	)
+	write := func(content string) {
+		if err := os.WriteFile(source, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	build := func() DAG {
+		count := NewOutput[int]("count")
+		b := NewDAGBuilder()
+		b.AddNodeWithOptions("count", Produce[int](count, concept.TaskFuncOf[int](func(ctx context.Context) (int, error) {
+			ran["count"]++
+			data, err := os.ReadFile(source)
+			return len(data), err
+		})), Produces(count), WithInputs(FileInput(filepath.Join(dir, "*.txt")), ParamInput("param", param)))
+		b.AddNodeWithOptions("report", T(func(ctx context.Context) error {
+			ran["report"]++
+			n, err := count.Get(ctx)
+			got = param + ":" + string(rune('0'+n))
+			return err
+		}), DependsOn("count"), Consumes(count), WithInputs(OutputInput("count")))
+		b.AddNodeWithOptions("read", T(func(ctx context.Context) error {
+			var err error
+			read, err = count.Get(ctx)
+			return err
+		}), DependsOn("count"), Consumes(count))
+		d, err := b.Build()
+		if err != nil {
+			t.Fatalf("DAG.Build() error = %v", err)
+		}
+		return d.WithFingerprintStore(store, "counting")
+	}
+
+	tests := []struct {
+		name     string
+		prepare  func()
+		want     map[string]int
+		wantGot  string
+		wantRead int
+	}{
+		{name: "first run", prepare: func() { write("abc") }, want: map[string]int{"count": 1, "report": 1}, wantGot: "v1:3", wantRead: 3},
+		{name: "up to date", prepare: func() {}, want: map[string]int{}, wantRead: 3},
+		{name: "same output", prepare: func() { write("xyz") }, want: map[string]int{"count": 1}, wantRead: 3},
+		{name: "changed file", prepare: func() { write("abcd") }, want: map[string]int{"count": 1, "report": 1}, wantGot: "v1:4", wantRead: 4},
+		{name: "changed param", prepare: func() { param = "v2" }, want: map[string]int{"count": 1}, wantRead: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare()
+			ran, got, read = map[string]int{}, "", 0
+			run := build().Start(context.Background())
+			if err := run.Wait(); err != nil {
+				t.Errorf("DAG.Do() error = %v", err)
+			}
+			if len(ran) != len(tt.want) || ran["count"] != tt.want["count"] || ran["report"] != tt.want["report"] {
+				t.Errorf("DAG.Do() ran %v, want %v", ran, tt.want)
+			}
+			if got != tt.wantGot || read != tt.wantRead {
+				t.Errorf("report got %q and read %d, want %q and %d", got, read, tt.wantGot, tt.wantRead)
+			}
+			for _, node := range run.Report().Nodes {
+				if node.Name == "read" {
+					continue
+				}
+				if node.State != Succeeded || node.UpToDate == (ran[node.Name] > 0) {
+					t.Errorf("RunReport node = %+v", node)
+				}
+			}
+		})
+	}
+
+	t.Run("missing input", func(t *testing.T) {
+		b := NewDAGBuilder()
+		b.AddNodeWithOptions("missing", T(func(ctx context.Context) error {
+			return nil
+		}), WithInputs(FileInput(filepath.Join(dir, "*.csv"))))
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		var te *TaskError
+		if err := d.WithFingerprintStore(store, "counting").Do(context.Background()); !errors.As(err, &te) || te.Name != "missing" {
+			t.Errorf("DAG.Do() error = %v, want the failure of missing", err)
+		}
+	})
+
+	t.Run("workflows sharing a store", func(t *testing.T) {
+		ran := map[string]int{}
+		build := func(workflow string) DAG {
+			b := NewDAGBuilder()
+			b.AddNodeWithOptions("build", T(func(ctx context.Context) error {
+				ran[workflow]++
+				return nil
+			}), WithInputs(ParamInput("param", "same")))
+			d, err := b.Build()
+			if err != nil {
+				t.Fatalf("DAG.Build() error = %v", err)
+			}
+			return d.WithFingerprintStore(store, workflow)
+		}
+		for _, workflow := range []string{"a", "b", "a", "b"} {
+			if err := build(workflow).Do(context.Background()); err != nil {
+				t.Errorf("DAG.Do() error = %v", err)
+			}
+		}
+		if ran["a"] != 1 || ran["b"] != 1 {
+			t.Errorf("DAG.Do() ran %v, want each workflow once", ran)
+		}
+	})
+
+	t.Run("inputs checked under the limiter", func(t *testing.T) {
+		var (
+			mu            sync.Mutex
+			hashing, most int
+		)
+		input := InputFunc(func(ctx context.Context) (string, error) {
+			mu.Lock()
+			hashing++
+			if hashing > most {
+				most = hashing
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			hashing--
+			mu.Unlock()
+			return "digest", nil
+		})
+		b := NewDAGBuilder()
+		for _, name := range []string{"a", "b", "c", "d"} {
+			b.AddNodeWithOptions(name, T(func(ctx context.Context) error {
+				return nil
+			}), WithInputs(input))
+		}
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		d = d.WithLimiter(NewLimiter(1)).WithFingerprintStore(store, "limited")
+		if err := d.Do(context.Background()); err != nil {
+			t.Errorf("DAG.Do() error = %v", err)
+		}
+		if most != 1 {
+			t.Errorf("inputs checked %d at a time, want 1", most)
+		}
+	})
+}
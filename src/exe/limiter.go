@@ -0,0 +1,138 @@
+package exe
+
+import (
+	"context"
+	"sync"
+)
+
+// Limiter bounds how many tasks run at the same time. Tasks waiting for a
+// slot are queued and served by decreasing priority, then in the order they
+// asked for one.
+//
+// A nil *Limiter, or one with a limit of zero or less, never blocks. A Limiter
+// may be shared by several executors, but not by an executor and the ones
+// nested in it, as the outer one would hold the slots the inner ones wait for.
+type Limiter struct {
+	mu      sync.Mutex
+	limit   int
+	running int
+	queue   []*ticket
+}
+
+// ticket is a queued request for a slot.
+type ticket struct {
+	ready    chan struct{}
+	priority int
+	granted  bool
+}
+
+// NewLimiter returns a Limiter that runs at most limit tasks at a time.
+func NewLimiter(limit int) *Limiter {
+	return &Limiter{limit: limit}
+}
+
+// Acquire waits for a slot, or until ctx is done. Every successful Acquire
+// must be followed by a Release.
+func (l *Limiter) Acquire(ctx context.Context) error {
+	return l.wait(ctx, l.reserve(0))
+}
+
+// Release frees a slot taken by Acquire.
+func (l *Limiter) Release() {
+	if l == nil || l.limit <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running--
+	for l.running < l.limit && len(l.queue) > 0 {
+		l.grant(l.queue[0])
+		l.queue = l.queue[1:]
+	}
+}
+
+// Running returns the number of tasks holding a slot.
+func (l *Limiter) Running() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.running
+}
+
+// Queued returns the number of tasks waiting for a slot.
+func (l *Limiter) Queued() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.queue)
+}
+
+// reserve takes a place in the queue. It does not block, so executors call it
+// in the order they want their tasks to be served.
+func (l *Limiter) reserve(priority int) *ticket {
+	t := &ticket{ready: make(chan struct{}), priority: priority}
+	if l == nil || l.limit <= 0 {
+		close(t.ready)
+		return t
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.running < l.limit && len(l.queue) == 0 {
+		l.grant(t)
+		return t
+	}
+	i := len(l.queue)
+	for i > 0 && l.queue[i-1].priority < priority {
+		i--
+	}
+	l.queue = append(l.queue, nil)
+	copy(l.queue[i+1:], l.queue[i:])
+	l.queue[i] = t
+	return t
+}
+
+// wait waits for t to be granted, or gives up its place when ctx is done.
+func (l *Limiter) wait(ctx context.Context, t *ticket) error {
+	if l == nil || l.limit <= 0 {
+		return nil
+	}
+	select {
+	case <-t.ready:
+		return nil
+	case <-ctx.Done():
+		l.cancel(t)
+		return ctx.Err()
+	}
+}
+
+// cancel gives up t: it leaves the queue, or frees its slot if it was
+// already granted.
+func (l *Limiter) cancel(t *ticket) {
+	if l == nil || l.limit <= 0 {
+		return
+	}
+	l.mu.Lock()
+	if !t.granted {
+		for i, queued := range l.queue {
+			if queued == t {
+				l.queue = append(l.queue[:i], l.queue[i+1:]...)
+				break
+			}
+		}
+		l.mu.Unlock()
+		return
+	}
+	l.mu.Unlock()
+	l.Release()
+}
+
+// grant hands a slot to t; l.mu must be held.
+func (l *Limiter) grant(t *ticket) {
+	l.running++
+	t.granted = true
+	close(t.ready)
+}
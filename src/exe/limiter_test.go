@@ -0,0 +1,145 @@
+package exe
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		var l *Limiter
+		if err := l.Acquire(context.Background()); err != nil {
+			t.Errorf("Limiter.Acquire() error = %v", err)
+		}
+		l.Release()
+		if l.Running() != 0 || l.Queued() != 0 {
+			t.Errorf("Limiter counts = %d/%d, want 0/0", l.Running(), l.Queued())
+		}
+	})
+
+	t.Run("queue", func(t *testing.T) {
+		l := NewLimiter(1)
+		if err := l.Acquire(context.Background()); err != nil {
+			t.Errorf("Limiter.Acquire() error = %v", err)
+			return
+		}
+		first, second := l.reserve(0), l.reserve(0)
+		if l.Running() != 1 || l.Queued() != 2 {
+			t.Errorf("Limiter counts = %d/%d, want 1/2", l.Running(), l.Queued())
+		}
+		l.Release()
+		select {
+		case <-first.ready:
+		default:
+			t.Errorf("Limiter.Release() did not serve the first ticket")
+		}
+		select {
+		case <-second.ready:
+			t.Errorf("Limiter.Release() served the second ticket out of order")
+		default:
+		}
+	})
+
+	t.Run("priority", func(t *testing.T) {
+		l := NewLimiter(1)
+		_ = l.Acquire(context.Background())
+		low, high, mid := l.reserve(-1), l.reserve(1), l.reserve(0)
+		for _, want := range []*ticket{high, mid, low} {
+			l.Release()
+			select {
+			case <-want.ready:
+			default:
+				t.Errorf("Limiter.Release() served tickets out of priority order")
+				return
+			}
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		l := NewLimiter(1)
+		_ = l.Acquire(context.Background())
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		if err := l.Acquire(ctx); err != context.DeadlineExceeded {
+			t.Errorf("Limiter.Acquire() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+		if l.Queued() != 0 {
+			t.Errorf("Limiter.Queued() = %d, want 0", l.Queued())
+		}
+	})
+}
+
+// concurrencyProbe returns n tasks recording the highest number of them
+// running at the same time in max.
+func concurrencyProbe(n int, max *int32) []concept.Task {
+	var (
+		mu      sync.Mutex
+		running int32
+	)
+	tasks := make([]concept.Task, n)
+	for i := range tasks {
+		tasks[i] = T(func(ctx context.Context) error {
+			mu.Lock()
+			running++
+			if running > atomic.LoadInt32(max) {
+				atomic.StoreInt32(max, running)
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond * 2)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		})
+	}
+	return tasks
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	t.Run("parallel", func(t *testing.T) {
+		var max int32
+		err := NewParallel(concurrencyProbe(10, &max)...).WithLimiter(NewLimiter(2)).Do(context.Background())
+		if err != nil || max != 2 {
+			t.Errorf("Parallel.Do() error = %v, max concurrency = %d, want 2", err, max)
+		}
+	})
+
+	t.Run("dag", func(t *testing.T) {
+		var max int32
+		b := NewDAGBuilder()
+		for i, task := range concurrencyProbe(10, &max) {
+			b.AddNode(strconv.Itoa(i), task)
+		}
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		limiter := NewLimiter(3)
+		if err = d.WithLimiter(limiter).Do(context.Background()); err != nil || max != 3 {
+			t.Errorf("DAG.Do() error = %v, max concurrency = %d, want 3", err, max)
+		}
+		if limiter.Running() != 0 || limiter.Queued() != 0 {
+			t.Errorf("Limiter counts = %d/%d after the run, want 0/0", limiter.Running(), limiter.Queued())
+		}
+	})
+
+	t.Run("global", func(t *testing.T) {
+		var max int32
+		tasks := concurrencyProbe(6, &max)
+		p := NewParallel(
+			NewParallel(tasks[:3]...),
+			concept.WithName(NewSerial(tasks[3:]...), "serial"),
+		)
+		ctx := SetLimiter(context.Background(), NewLimiter(1))
+		if err := p.Do(ctx); err != nil || max != 1 {
+			t.Errorf("Parallel.Do() error = %v, max concurrency = %d, want 1", err, max)
+		}
+	})
+}
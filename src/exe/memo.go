@@ -0,0 +1,195 @@
+package exe
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+var (
+	_ concept.AOP = Memo{}
+	_ error       = (*CachedError)(nil)
+)
+
+// KeyFunc returns the key identifying the work of the task run in ctx. An
+// empty key opts the task out.
+type KeyFunc func(ctx context.Context) string
+
+// NameKey returns a KeyFunc identifying a task by its name, as reported by
+// GetTaskInfo, and by the key returned by run, if not nil, such as the date
+// the task processes. Tasks with the same name are taken for the same work,
+// wherever they run, so names must be unique among the tasks sharing a cache.
+// Tasks without a name of their own, which executors name after their index,
+// get an empty key.
+func NameKey(run KeyFunc) KeyFunc {
+	return func(ctx context.Context) string {
+		info, ok := GetTaskInfo(ctx)
+		if !ok || info.Name == "" || info.Name == strconv.Itoa(info.Index) {
+			return ""
+		}
+		key := strconv.Quote(info.Name)
+		if run != nil {
+			runKey := run(ctx)
+			if runKey == "" {
+				return ""
+			}
+			key += ":" + runKey
+		}
+		return key
+	}
+}
+
+// CachedError is the error of a task returned by a Memo from its cache.
+type CachedError struct {
+	// Err is the cached error. Caches encoding their entries, such as
+	// DiskCache, only keep its message.
+	Err error
+}
+
+func (e *CachedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the cached error.
+func (e *CachedError) Unwrap() error {
+	return e.Err
+}
+
+// Memo is an AOP that caches the outcomes of tasks by key, so that a task
+// whose key has a valid entry is not run again. Successes are cached for the
+// TTL of the Memo, and failures only if it has an error TTL. Tasks cancelled
+// by their context are never cached.
+//
+// As an AOP, Memo only caches whether the task failed; MemoizeOf caches the
+// results of a TaskOf as well.
+type Memo struct {
+	cache    Cache
+	key      KeyFunc
+	ttl      time.Duration
+	errorTTL time.Duration
+}
+
+// MemoOption configures a Memo.
+type MemoOption func(*Memo)
+
+// NewMemo returns a Memo keeping the outcomes of tasks in cache, under the
+// key returned by key, such as NameKey(nil). Unless configured otherwise,
+// successes never expire and failures are not cached.
+func NewMemo(cache Cache, key KeyFunc, opts ...MemoOption) Memo {
+	m := Memo{cache: cache, key: key}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	return m
+}
+
+// MemoTTL makes successes expire d after they are cached. A TTL of zero or
+// less never expires.
+func MemoTTL(d time.Duration) MemoOption {
+	return func(m *Memo) {
+		m.ttl = d
+	}
+}
+
+// MemoErrorTTL caches failures for d. A TTL of zero or less does not cache
+// them. Cached failures are returned as a *CachedError; errors.Is and
+// errors.As see through it to the error of the task with an LRUCache, but
+// not with a DiskCache, which only keeps the message of the error.
+func MemoErrorTTL(d time.Duration) MemoOption {
+	return func(m *Memo) {
+		m.errorTTL = d
+	}
+}
+
+// Apply returns a TaskFunc that returns the cached outcome of f, if any, and
+// runs f and caches its outcome otherwise.
+func (m Memo) Apply(f concept.TaskFunc) concept.TaskFunc {
+	return func(ctx context.Context) error {
+		key := m.key(ctx)
+		if key == "" {
+			return f(ctx)
+		}
+		if entry, ok := m.cache.Get(key); ok {
+			return cachedErr(entry)
+		}
+		err := f(ctx)
+		m.store(ctx, key, nil, err)
+		return err
+	}
+}
+
+// store caches the outcome of a task run in ctx.
+func (m Memo) store(ctx context.Context, key string, value interface{}, err error) {
+	ttl := m.ttl
+	if err != nil {
+		if m.errorTTL <= 0 || ctx.Err() != nil {
+			return
+		}
+		ttl, value = m.errorTTL, nil
+	}
+	entry := CacheEntry{Value: value, Err: err}
+	if ttl > 0 {
+		entry.Expires = time.Now().Add(ttl)
+	}
+	m.cache.Set(key, entry)
+}
+
+// MemoizeOf returns a TaskOf returning the cached result of task, if any, and
+// running task and caching its result otherwise, as m does for the tasks it
+// is applied to. Caches encoding their entries need T to be encoded in JSON.
+func MemoizeOf[T any](task concept.TaskOf[T], m Memo) concept.TaskOf[T] {
+	return memoTask[T]{task: task, memo: m}
+}
+
+// memoTask is a TaskOf memoized by a Memo.
+type memoTask[T any] struct {
+	task concept.TaskOf[T]
+	memo Memo
+}
+
+func (t memoTask[T]) Do(ctx context.Context) (T, error) {
+	key := t.memo.key(ctx)
+	if key == "" {
+		return t.task.Do(ctx)
+	}
+	if entry, ok := t.memo.cache.Get(key); ok {
+		if value, ok := cachedValue[T](entry); ok {
+			return value, cachedErr(entry)
+		}
+	}
+	value, err := t.task.Do(ctx)
+	t.memo.store(ctx, key, value, err)
+	return value, err
+}
+
+// wrapped returns the memoized task.
+func (t memoTask[T]) wrapped() interface{} {
+	return t.task
+}
+
+// cachedErr returns the error of entry as a *CachedError, or nil.
+func cachedErr(entry CacheEntry) error {
+	if entry.Err == nil {
+		return nil
+	}
+	return &CachedError{Err: entry.Err}
+}
+
+// cachedValue returns the value of entry as a T, and false if it is not one.
+func cachedValue[T any](entry CacheEntry) (T, bool) {
+	var value T
+	switch cached := entry.Value.(type) {
+	case nil:
+		return value, entry.Err != nil
+	case T:
+		return cached, true
+	case json.RawMessage:
+		err := json.Unmarshal(cached, &value)
+		return value, err == nil
+	default:
+		return value, false
+	}
+}
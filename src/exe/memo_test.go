@@ -0,0 +1,306 @@
+package exe
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+func TestMemo(t *testing.T) {
+	errFailed := errors.New("failed")
+	tests := []struct {
+		name  string
+		cache func(t *testing.T) Cache
+		opts  []MemoOption
+		key   KeyFunc
+		errs  []error
+		wait  time.Duration
+		runs  int
+		want  []error
+	}{
+		{
+			name:  "cached",
+			cache: func(*testing.T) Cache { return NewLRUCache(0) },
+			key:   NameKey(nil),
+			errs:  []error{nil, nil, nil},
+			runs:  1,
+			want:  []error{nil, nil, nil},
+		},
+		{
+			name:  "empty key",
+			cache: func(*testing.T) Cache { return NewLRUCache(0) },
+			key:   func(context.Context) string { return "" },
+			errs:  []error{nil, nil},
+			runs:  2,
+			want:  []error{nil, nil},
+		},
+		{
+			name:  "expired",
+			cache: func(*testing.T) Cache { return NewLRUCache(0) },
+			opts:  []MemoOption{MemoTTL(time.Millisecond)},
+			key:   NameKey(nil),
+			errs:  []error{nil, nil},
+			wait:  time.Millisecond * 5,
+			runs:  2,
+			want:  []error{nil, nil},
+		},
+		{
+			name:  "errors not cached",
+			cache: func(*testing.T) Cache { return NewLRUCache(0) },
+			key:   NameKey(nil),
+			errs:  []error{errFailed, nil, nil},
+			runs:  2,
+			want:  []error{errFailed, nil, nil},
+		},
+		{
+			name:  "errors cached",
+			cache: func(*testing.T) Cache { return NewLRUCache(0) },
+			opts:  []MemoOption{MemoErrorTTL(time.Minute)},
+			key:   NameKey(nil),
+			errs:  []error{errFailed, nil},
+			runs:  1,
+			want:  []error{errFailed, errFailed},
+		},
+		{
+			name:  "disk",
+			cache: func(t *testing.T) Cache { return NewDiskCache(t.TempDir()) },
+			opts:  []MemoOption{MemoErrorTTL(time.Minute)},
+			key:   NameKey(nil),
+			errs:  []error{errFailed, nil},
+			runs:  1,
+			want:  []error{errFailed, errFailed},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				memo = NewMemo(tt.cache(t), tt.key, tt.opts...)
+				runs int
+			)
+			task := concept.TaskFunc(func(ctx context.Context) error {
+				err := tt.errs[runs]
+				runs++
+				return err
+			})
+			ctx := SetTaskInfo(context.Background(), concept.TaskInfo{Meta: concept.Meta{Name: "task"}, Path: []string{"task"}})
+			for i, want := range tt.want {
+				if i > 0 {
+					time.Sleep(tt.wait)
+				}
+				err := memo.Apply(task.Do)(ctx)
+				if (err == nil) != (want == nil) || (err != nil && err.Error() != want.Error()) {
+					t.Errorf("call %d: got error %v, want %v", i, err, want)
+				}
+			}
+			if runs != tt.runs {
+				t.Errorf("got %d runs, want %d", runs, tt.runs)
+			}
+		})
+	}
+}
+
+func TestMemo_executors(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		runs map[string]int
+	)
+	count := func(counter string) concept.Task {
+		return concept.TaskFunc(func(ctx context.Context) error {
+			mu.Lock()
+			runs[counter]++
+			mu.Unlock()
+			return nil
+		})
+	}
+	named := func(name, counter string) concept.Task {
+		return concept.WithMeta(count(counter), concept.Meta{Name: name})
+	}
+	tests := []struct {
+		name  string
+		tasks []concept.Task
+		want  map[string]int
+	}{
+		{
+			name: "same name",
+			tasks: []concept.Task{
+				NewSerial(named("fetch", "first")),
+				NewParallel(named("fetch", "second"), NewSerial(named("fetch", "third"))),
+			},
+			want: map[string]int{"first": 1},
+		},
+		{
+			name: "different names",
+			tasks: []concept.Task{
+				NewSerial(named("a", "a"), named("b", "b")),
+				NewParallel(named("a", "a"), named("b", "b")),
+			},
+			want: map[string]int{"a": 1, "b": 1},
+		},
+		{
+			name: "unnamed",
+			tasks: []concept.Task{
+				NewSerial(count("first"), count("second")),
+				NewSerial(count("third")),
+				NewParallel(count("fourth"), count("fifth")),
+			},
+			want: map[string]int{"first": 1, "second": 1, "third": 1, "fourth": 1, "fifth": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs = map[string]int{}
+			ctx := SetAOP(context.Background(), NewMemo(NewLRUCache(0), NameKey(nil)))
+			for i, task := range tt.tasks {
+				if err := task.Do(ctx); err != nil {
+					t.Errorf("task %d: %v", i, err)
+				}
+			}
+			if !reflect.DeepEqual(runs, tt.want) {
+				t.Errorf("got runs %v, want %v", runs, tt.want)
+			}
+		})
+	}
+}
+
+func TestCachedError(t *testing.T) {
+	errFailed := errors.New("failed")
+	tests := []struct {
+		name   string
+		cache  func(t *testing.T) Cache
+		wantIs bool
+	}{
+		{
+			name:   "lru",
+			cache:  func(*testing.T) Cache { return NewLRUCache(0) },
+			wantIs: true,
+		},
+		{
+			name:   "disk",
+			cache:  func(t *testing.T) Cache { return NewDiskCache(t.TempDir()) },
+			wantIs: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := NewMemo(tt.cache(t), NameKey(nil), MemoErrorTTL(time.Minute)).Apply(func(ctx context.Context) error {
+				return errFailed
+			})
+			ctx := SetTaskInfo(context.Background(), concept.TaskInfo{Meta: concept.Meta{Name: "task"}, Path: []string{"task"}})
+			if err := task(ctx); err != errFailed {
+				t.Errorf("first call: got error %v, want %v", err, errFailed)
+			}
+			err := task(ctx)
+			var cached *CachedError
+			if !errors.As(err, &cached) || err.Error() != errFailed.Error() {
+				t.Errorf("second call: got error %v, want a *CachedError", err)
+			}
+			if errors.Is(err, errFailed) != tt.wantIs {
+				t.Errorf("errors.Is() = %v, want %v", !tt.wantIs, tt.wantIs)
+			}
+		})
+	}
+}
+
+func TestMemoCancelled(t *testing.T) {
+	var (
+		memo = NewMemo(NewLRUCache(0), NameKey(nil), MemoErrorTTL(time.Minute))
+		runs int
+	)
+	task := memo.Apply(func(ctx context.Context) error {
+		runs++
+		return ctx.Err()
+	})
+	ctx, cancel := context.WithCancel(SetTaskInfo(context.Background(), concept.TaskInfo{Meta: concept.Meta{Name: "task"}, Path: []string{"task"}}))
+	cancel()
+	task(ctx)
+	task(ctx)
+	if runs != 2 {
+		t.Errorf("got %d runs, want 2", runs)
+	}
+}
+
+func TestMemoizeOf(t *testing.T) {
+	tests := []struct {
+		name  string
+		cache func(t *testing.T) Cache
+	}{
+		{
+			name:  "lru",
+			cache: func(*testing.T) Cache { return NewLRUCache(1) },
+		},
+		{
+			name:  "disk",
+			cache: func(t *testing.T) Cache { return NewDiskCache(t.TempDir()) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				runs  int
+				cache = tt.cache(t)
+			)
+			run := func(ctx context.Context) string {
+				n, _ := ctx.Value(memoTestKey{}).(string)
+				return n
+			}
+			task := MemoizeOf[[]string](concept.TaskFuncOf[[]string](func(ctx context.Context) ([]string, error) {
+				runs++
+				return []string{run(ctx), "done"}, nil
+			}), NewMemo(cache, NameKey(run)))
+			for i, name := range []string{"a", "a", "b", "a"} {
+				ctx := context.WithValue(context.Background(), memoTestKey{}, name)
+				ctx = SetTaskInfo(ctx, concept.TaskInfo{Meta: concept.Meta{Name: "task"}, Path: []string{"task"}})
+				got, err := task.Do(ctx)
+				if err != nil {
+					t.Fatalf("call %d: %v", i, err)
+				}
+				if len(got) != 2 || got[0] != name || got[1] != "done" {
+					t.Errorf("call %d: got %v", i, got)
+				}
+			}
+			want := 2
+			if _, ok := cache.(*LRUCache); ok {
+				// b evicts a from a cache of one entry
+				want = 3
+			}
+			if runs != want {
+				t.Errorf("got %d runs, want %d", runs, want)
+			}
+		})
+	}
+}
+
+type memoTestKey struct{}
+
+func TestLRUCache(t *testing.T) {
+	cache := NewLRUCache(2)
+	cache.Set("a", CacheEntry{Value: 1})
+	cache.Set("b", CacheEntry{Value: 2})
+	cache.Get("a")
+	cache.Set("c", CacheEntry{Value: 3})
+	cache.Set("d", CacheEntry{Value: 4, Expires: time.Now().Add(-time.Second)})
+	tests := []struct {
+		key  string
+		want interface{}
+		ok   bool
+	}{
+		{key: "a", ok: false},
+		{key: "b", ok: false},
+		{key: "c", want: 3, ok: true},
+		{key: "d", ok: false},
+	}
+	for _, tt := range tests {
+		entry, ok := cache.Get(tt.key)
+		if ok != tt.ok || entry.Value != tt.want {
+			t.Errorf("%s: got %v, %v, want %v, %v", tt.key, entry.Value, ok, tt.want, tt.ok)
+		}
+	}
+	if cache.Len() != 1 {
+		t.Errorf("got %d entries, want 1", cache.Len())
+	}
+}
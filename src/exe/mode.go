@@ -0,0 +1,41 @@
+package exe
+
+import "fmt"
+
+// FailureMode controls how an executor reacts when one of its children fails.
+type FailureMode int
+
+const (
+	// FailFast cancels the remaining children and returns the first error
+	// without waiting for them to return.
+	FailFast FailureMode = iota
+	// WaitAll cancels the remaining children on the first error and waits
+	// for all of them to return before reporting it.
+	WaitAll
+	// CollectAll lets every child run to completion and reports all errors.
+	CollectAll
+)
+
+// String returns the name of the mode.
+func (m FailureMode) String() string {
+	switch m {
+	case FailFast:
+		return "fail-fast"
+	case WaitAll:
+		return "wait-all"
+	case CollectAll:
+		return "collect-all"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseFailureMode returns the mode called name, as returned by String.
+func ParseFailureMode(name string) (FailureMode, error) {
+	for m := FailFast; m <= CollectAll; m++ {
+		if m.String() == name {
+			return m, nil
+		}
+	}
+	return FailFast, fmt.Errorf("unknown failure mode %q", name)
+}
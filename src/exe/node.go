@@ -0,0 +1,305 @@
+package exe
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+// NodeOption configures a node added to a DAGBuilder.
+type NodeOption func(*nodeOptions)
+
+// FailurePolicy decides how the failure of a DAG node affects the run.
+type FailurePolicy int
+
+const (
+	// FailRun reports the failure and handles it according to the mode of
+	// the DAG.
+	FailRun FailurePolicy = iota
+	// ContinueRun reports the failure but keeps running the nodes that do
+	// not depend on the failed one, whatever the mode of the DAG.
+	ContinueRun
+	// IgnoreFailure does not report the failure, and runs the nodes that
+	// depend on the failed one as if it had succeeded.
+	IgnoreFailure
+)
+
+// String returns the name of the policy.
+func (p FailurePolicy) String() string {
+	switch p {
+	case FailRun:
+		return "fail-run"
+	case ContinueRun:
+		return "continue-run"
+	case IgnoreFailure:
+		return "ignore-failure"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseFailurePolicy returns the policy called name, as returned by String.
+func ParseFailurePolicy(name string) (FailurePolicy, error) {
+	for p := FailRun; p <= IgnoreFailure; p++ {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return FailRun, fmt.Errorf("unknown failure policy %q", name)
+}
+
+// TriggerRule decides, from the final states of the nodes a DAG node waits
+// for, whether the node runs. A node that waits for no other node always
+// runs.
+type TriggerRule int
+
+const (
+	// AllSuccess runs the node once every node it waits for has succeeded.
+	// The node is upstream_failed as soon as one of them fails, and skipped
+	// as soon as one of them is skipped.
+	AllSuccess TriggerRule = iota
+	// AllDone runs the node once every node it waits for is done, whatever
+	// their state.
+	AllDone
+	// OneSuccess runs the node as soon as one of the nodes it waits for has
+	// succeeded. If none does, the node is upstream_failed when one of them
+	// failed, and skipped otherwise.
+	OneSuccess
+	// OneFailed runs the node as soon as one of the nodes it waits for has
+	// failed, and skips it if none does.
+	OneFailed
+	// NoneFailed runs the node once every node it waits for has succeeded or
+	// been skipped. The node is upstream_failed as soon as one of them fails.
+	NoneFailed
+	// Always runs the node once every node it waits for is done, like
+	// AllDone, and still does when the run is aborted, against a context
+	// that is only cancelled with the caller's one.
+	Always
+)
+
+// String returns the name of the rule.
+func (r TriggerRule) String() string {
+	switch r {
+	case AllSuccess:
+		return "all_success"
+	case AllDone:
+		return "all_done"
+	case OneSuccess:
+		return "one_success"
+	case OneFailed:
+		return "one_failed"
+	case NoneFailed:
+		return "none_failed"
+	case Always:
+		return "always"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseTriggerRule returns the rule called name, as returned by String.
+func ParseTriggerRule(name string) (TriggerRule, error) {
+	for r := AllSuccess; r <= Always; r++ {
+		if r.String() == name {
+			return r, nil
+		}
+	}
+	return AllSuccess, fmt.Errorf("unknown trigger rule %q", name)
+}
+
+// upstreamCounts tallies the final states of the nodes a DAG node waits for.
+// Failures count as successes for a node whose failure policy is
+// IgnoreFailure, and cancelled nodes count as failures.
+type upstreamCounts struct {
+	done      int
+	succeeded int
+	failed    int
+	skipped   int
+}
+
+// decide returns the fate of a node waiting for total nodes, given the states
+// of the ones that are done: Running to run it, Pending to keep waiting, or
+// the final state of the node.
+func (r TriggerRule) decide(c upstreamCounts, total int) NodeState {
+	all := c.done == total
+	switch r {
+	case AllSuccess:
+		switch {
+		case c.failed > 0:
+			return UpstreamFailed
+		case c.skipped > 0:
+			return Skipped
+		case all:
+			return Running
+		}
+	case AllDone, Always:
+		if all {
+			return Running
+		}
+	case OneSuccess:
+		switch {
+		case c.succeeded > 0:
+			return Running
+		case all && c.failed > 0:
+			return UpstreamFailed
+		case all:
+			return Skipped
+		}
+	case OneFailed:
+		switch {
+		case c.failed > 0:
+			return Running
+		case all:
+			return Skipped
+		}
+	case NoneFailed:
+		switch {
+		case c.failed > 0:
+			return UpstreamFailed
+		case all:
+			return Running
+		}
+	}
+	return Pending
+}
+
+// nodeOptions is the configuration of a DAG node.
+type nodeOptions struct {
+	before    []string
+	after     []string
+	timeout   time.Duration
+	retry     *Retry
+	priority  int
+	labels    map[string]string
+	aops      concept.AOPs
+	onFailure FailurePolicy
+	trigger   TriggerRule
+	produces  []outputDecl
+	consumes  []outputDecl
+	inputs    []Input
+}
+
+// DependsOn makes the node run after the nodes named, or matching as a
+// regexp, any of deps.
+func DependsOn(deps ...string) NodeOption {
+	return func(o *nodeOptions) {
+		o.after = append(o.after, deps...)
+	}
+}
+
+// Before makes the node run before the nodes named, or matching as a regexp,
+// any of next. This is the meaning of the deps of DAGBuilder.AddNode.
+func Before(next ...string) NodeOption {
+	return func(o *nodeOptions) {
+		o.before = append(o.before, next...)
+	}
+}
+
+// Feeds is an alias of Before, for nodes whose output the next ones consume.
+func Feeds(next ...string) NodeOption {
+	return Before(next...)
+}
+
+// WithTimeout bounds every attempt of the node to d.
+func WithTimeout(d time.Duration) NodeOption {
+	return func(o *nodeOptions) {
+		o.timeout = d
+	}
+}
+
+// WithRetry runs the node again according to r when it fails. Each attempt
+// gets its own timeout.
+func WithRetry(r Retry) NodeOption {
+	return func(o *nodeOptions) {
+		o.retry = &r
+	}
+}
+
+// WithPriority sets the priority of the node. When more nodes are ready than
+// the limiter of the DAG, or the Limiter in the context, lets run, the ones
+// with the highest priority start first. Nodes default to a priority of zero.
+func WithPriority(priority int) NodeOption {
+	return func(o *nodeOptions) {
+		o.priority = priority
+	}
+}
+
+// WithLabels adds labels to the node, on top of the ones of its task.
+func WithLabels(labels map[string]string) NodeOption {
+	return func(o *nodeOptions) {
+		if o.labels == nil {
+			o.labels = make(map[string]string, len(labels))
+		}
+		for k, v := range labels {
+			o.labels[k] = v
+		}
+	}
+}
+
+// WithNodeAOP applies aops to every attempt of the node, inside the AOP of
+// the context.
+func WithNodeAOP(aops ...concept.AOP) NodeOption {
+	return func(o *nodeOptions) {
+		o.aops = append(o.aops, aops...)
+	}
+}
+
+// WithFailurePolicy sets how a failure of the node affects the run.
+func WithFailurePolicy(policy FailurePolicy) NodeOption {
+	return func(o *nodeOptions) {
+		o.onFailure = policy
+	}
+}
+
+// WithTrigger sets the rule deciding whether the node runs, from the final
+// states of the nodes it waits for. Nodes default to AllSuccess.
+func WithTrigger(rule TriggerRule) NodeOption {
+	return func(o *nodeOptions) {
+		o.trigger = rule
+	}
+}
+
+// newNodeOptions applies opts to the default node configuration.
+func newNodeOptions(opts []NodeOption) nodeOptions {
+	var o nodeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// decorate returns task wrapped by the AOPs implementing the options: the
+// ones of the node, then the timeout, then the retries.
+func (o nodeOptions) decorate(task concept.Task) concept.Task {
+	aops := append(concept.AOPs(nil), o.aops...)
+	if o.timeout > 0 {
+		aops = append(aops, Timeout(o.timeout))
+	}
+	if o.retry != nil {
+		aops = append(aops, *o.retry)
+	}
+	if len(aops) == 0 {
+		return task
+	}
+	return WithAOP(task, aops...)
+}
+
+// meta returns the metadata of task with the labels of the options added.
+func (o nodeOptions) meta(task concept.Task) concept.Meta {
+	var meta concept.Meta
+	if task != nil {
+		meta = concept.MetaOf(task)
+	}
+	if len(o.labels) > 0 {
+		labels := make(map[string]string, len(meta.Labels)+len(o.labels))
+		for k, v := range meta.Labels {
+			labels[k] = v
+		}
+		for k, v := range o.labels {
+			labels[k] = v
+		}
+		meta.Labels = labels
+	}
+	return meta
+}
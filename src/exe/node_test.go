@@ -0,0 +1,204 @@
+package exe
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+func TestNodeOptions(t *testing.T) {
+	t.Run("retry and timeout", func(t *testing.T) {
+		var attempts int
+		b := NewDAGBuilder()
+		b.AddNodeWithOptions("flaky", T(func(ctx context.Context) error {
+			attempts++
+			if attempts < 3 {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		}), WithTimeout(time.Millisecond), WithRetry(NewRetry()))
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		if err = d.Do(context.Background()); err != nil || attempts != 3 {
+			t.Errorf("DAG.Do() error = %v after %d attempts", err, attempts)
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		var attempts int
+		b := NewDAGBuilder()
+		b.AddNodeWithOptions("flaky", T(func(ctx context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return errTest
+			}
+			return nil
+		}), WithRetry(NewRetry()))
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		if err = d.Do(context.Background()); err != nil || attempts != 3 {
+			t.Errorf("DAG.Do() error = %v after %d attempts", err, attempts)
+		}
+	})
+
+	t.Run("priority", func(t *testing.T) {
+		var order []string
+		b := NewDAGBuilder()
+		for _, name := range []string{"low", "high", "mid"} {
+			name := name
+			priority := map[string]int{"low": -1, "high": 10, "mid": 0}[name]
+			b.AddNodeWithOptions(name, T(func(ctx context.Context) error {
+				order = append(order, name)
+				return nil
+			}), WithPriority(priority))
+		}
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		if err = d.WithLimiter(NewLimiter(1)).Do(context.Background()); err != nil {
+			t.Errorf("DAG.Do() error = %v", err)
+		}
+		if strings.Join(order, ",") != "high,mid,low" {
+			t.Errorf("DAG.Do() ran %v, want high,mid,low", order)
+		}
+	})
+
+	t.Run("global priority", func(t *testing.T) {
+		var order []string
+		b := NewDAGBuilder()
+		for _, name := range []string{"low", "high", "mid"} {
+			name := name
+			priority := map[string]int{"low": -1, "high": 10, "mid": 0}[name]
+			b.AddNodeWithOptions(name, T(func(ctx context.Context) error {
+				order = append(order, name)
+				return nil
+			}), WithPriority(priority))
+		}
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		// hold the only slot until every node waits for it, behind a task
+		// of priority zero
+		global := NewLimiter(1)
+		global.Acquire(context.Background())
+		outside := make(chan struct{})
+		go func() {
+			global.Acquire(context.Background())
+			order = append(order, "outside")
+			global.Release()
+			close(outside)
+		}()
+		for global.Queued() < 1 {
+			time.Sleep(time.Millisecond)
+		}
+		done := make(chan error)
+		go func() {
+			done <- d.Do(SetLimiter(context.Background(), global))
+		}()
+		for global.Queued() < 4 {
+			time.Sleep(time.Millisecond)
+		}
+		global.Release()
+		if err = <-done; err != nil {
+			t.Errorf("DAG.Do() error = %v", err)
+		}
+		<-outside
+		if strings.Join(order, ",") != "high,outside,mid,low" {
+			t.Errorf("DAG.Do() ran %v, want high,outside,mid,low", order)
+		}
+	})
+
+	t.Run("labels and aop", func(t *testing.T) {
+		var (
+			labels  map[string]string
+			applied bool
+		)
+		b := NewDAGBuilder()
+		b.AddNodeWithOptions("db", concept.WithMeta(T(func(ctx context.Context) error {
+			info, _ := GetTaskInfo(ctx)
+			labels = info.Labels
+			return nil
+		}), concept.Meta{Labels: map[string]string{"kind": "db", "team": "a"}}),
+			WithLabels(map[string]string{"team": "b"}),
+			WithNodeAOP(&TestAOP{
+				f: func(f concept.TaskFunc) concept.TaskFunc {
+					applied = true
+					return f
+				},
+			}),
+		)
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		if err = d.Do(context.Background()); err != nil {
+			t.Errorf("DAG.Do() error = %v", err)
+		}
+		if labels["kind"] != "db" || labels["team"] != "b" {
+			t.Errorf("GetTaskInfo() labels = %v", labels)
+		}
+		if nodes := d.Nodes(); nodes[0].Labels["team"] != "b" {
+			t.Errorf("DAG.Nodes() = %+v", nodes)
+		}
+		if !applied {
+			t.Errorf("node AOP was not applied")
+		}
+	})
+
+	t.Run("failure policy", func(t *testing.T) {
+		var (
+			mu  sync.Mutex
+			ran []string
+		)
+		record := func(name string, err error) concept.Task {
+			return T(func(ctx context.Context) error {
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				ran = append(ran, name)
+				mu.Unlock()
+				return err
+			})
+		}
+		b := NewDAGBuilder()
+		b.AddNodeWithOptions("ignored", record("ignored", errTest), Before("after-ignored"), WithFailurePolicy(IgnoreFailure))
+		b.AddNode("after-ignored", record("after-ignored", nil))
+		b.AddNodeWithOptions("continued", record("continued", errTest), Before("after-continued"), WithFailurePolicy(ContinueRun))
+		b.AddNode("after-continued", record("after-continued", nil))
+		b.AddNode("independent", record("independent", nil), "after-independent")
+		b.AddNode("after-independent", record("after-independent", nil))
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		err = d.Do(context.Background())
+		var te *TaskError
+		if !errors.As(err, &te) || te.Name != "continued" {
+			t.Errorf("DAG.Do() error = %v, want the failure of continued", err)
+		}
+		got := map[string]bool{}
+		for _, name := range ran {
+			got[name] = true
+		}
+		if !got["after-ignored"] || !got["after-independent"] || got["after-continued"] {
+			t.Errorf("DAG.Do() ran %v", ran)
+		}
+	})
+}
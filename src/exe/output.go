@@ -0,0 +1,248 @@
+package exe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	ErrOutputUnknown  = errors.New("unknown output in DAG")
+	ErrOutputType     = errors.New("output type mismatch in DAG")
+	ErrOutputOrder    = errors.New("output read by a node not waiting for its producer")
+	ErrOutputNotReady = errors.New("output not ready")
+)
+
+// Output is a handle on the value of type T produced by the DAG node named
+// after it. The producer declares it with Produces and sets it with Set; the
+// nodes downstream of the producer declare it with Consumes and read it with
+// Get.
+type Output[T any] struct {
+	node string
+}
+
+// NewOutput returns a handle on the output of the node called node.
+func NewOutput[T any](node string) Output[T] {
+	return Output[T]{node: node}
+}
+
+// Node returns the name of the node producing o.
+func (o Output[T]) Node() string {
+	return o.node
+}
+
+// Set sets the value of o. It must be called by the producer of o, and the
+// value becomes visible once the producer succeeds.
+func (o Output[T]) Set(ctx context.Context, value T) error {
+	store := getOutputs(ctx)
+	if store == nil {
+		return fmt.Errorf("%w: %s is set outside of a DAG", ErrOutputUnknown, o.node)
+	}
+	return store.set(ctx, o.node, value)
+}
+
+// Get returns the value of o. It fails with ErrOutputNotReady if the producer
+// of o did not succeed, or did not set it.
+func (o Output[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	value, err := getOutputs(ctx).get(o.node)
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := value.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s is a %T, not a %T", ErrOutputType, o.node, value, zero)
+	}
+	return typed, nil
+}
+
+// Produces declares that the node produces o, which must be named after it.
+func Produces[T any](o Output[T]) NodeOption {
+	return func(opts *nodeOptions) {
+		opts.produces = append(opts.produces, newOutputDecl[T](o.node))
+	}
+}
+
+// Consumes declares that the node reads o. The node must run after the
+// producer of o, and both must agree on the type of o.
+func Consumes[T any](o Output[T]) NodeOption {
+	return func(opts *nodeOptions) {
+		opts.consumes = append(opts.consumes, newOutputDecl[T](o.node))
+	}
+}
+
+// outputDecl is the declaration of an output by its producer or a consumer.
+type outputDecl struct {
+	node string
+	typ  reflect.Type
+	// decode decodes a value of the output encoded in JSON.
+	decode func(data []byte) (interface{}, error)
+}
+
+func newOutputDecl[T any](node string) outputDecl {
+	return outputDecl{
+		node: node,
+		typ:  reflect.TypeOf((*T)(nil)).Elem(),
+		decode: func(data []byte) (interface{}, error) {
+			var value T
+			err := json.Unmarshal(data, &value)
+			return value, err
+		},
+	}
+}
+
+// checkOutputs checks that every output consumed in the DAG is produced with
+// the same type by a node the consumer waits for.
+func checkOutputs(d DAG) error {
+	var (
+		index    = make(map[string]int, len(d.nodes))
+		produced = make(map[string]reflect.Type)
+	)
+	for i := range d.nodes {
+		index[d.name(i)] = i
+		for _, decl := range d.option(i).produces {
+			if decl.node != d.name(i) {
+				return fmt.Errorf("%w: %s produces the output of %s", ErrOutputUnknown, d.name(i), decl.node)
+			}
+			produced[decl.node] = decl.typ
+		}
+	}
+	for i := range d.nodes {
+		for _, decl := range d.option(i).consumes {
+			typ, ok := produced[decl.node]
+			if !ok {
+				return fmt.Errorf("%w: %s consumes %s, which produces no output", ErrOutputUnknown, d.name(i), decl.node)
+			}
+			if typ != decl.typ {
+				return fmt.Errorf("%w: %s consumes %s as a %v, but it produces a %v", ErrOutputType, d.name(i), decl.node, decl.typ, typ)
+			}
+			if !d.reaches(index[decl.node], i) {
+				return fmt.Errorf("%w: %s consumes %s", ErrOutputOrder, d.name(i), decl.node)
+			}
+		}
+	}
+	return nil
+}
+
+// reaches reports whether there is a path of edges from one node to another.
+func (d DAG) reaches(from, to int) bool {
+	visited := make([]bool, len(d.nodes))
+	stack := []int{from}
+	for len(stack) > 0 {
+		index := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, next := range d.edges[index] {
+			if next == to {
+				return true
+			}
+			if !visited[next] {
+				visited[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return false
+}
+
+type outputsKeyType struct{}
+
+var outputsKey outputsKeyType
+
+// getOutputs returns the outputs of the innermost DAG run in the context.
+func getOutputs(ctx context.Context) *outputStore {
+	store, _ := ctx.Value(outputsKey).(*outputStore)
+	return store
+}
+
+// outputStore holds the outputs of a DAG run.
+type outputStore struct {
+	mu     sync.Mutex
+	parent *outputStore
+	path   []string
+	nodes  map[string]bool
+	values map[string]interface{}
+	done   map[string]bool
+}
+
+// newOutputStore returns the store of a run of d in ctx, which falls back to
+// the store of the enclosing run for the nodes d does not have.
+func newOutputStore(ctx context.Context, d DAG) *outputStore {
+	info, _ := GetTaskInfo(ctx)
+	store := &outputStore{
+		parent: getOutputs(ctx),
+		path:   info.Path,
+		nodes:  make(map[string]bool, len(d.nodes)),
+		values: make(map[string]interface{}),
+		done:   make(map[string]bool),
+	}
+	for i := range d.nodes {
+		store.nodes[d.name(i)] = true
+	}
+	return store
+}
+
+// with returns a context carrying s.
+func (s *outputStore) with(ctx context.Context) context.Context {
+	return context.WithValue(ctx, outputsKey, s)
+}
+
+// set records the value of the output of node, if the task in ctx is node
+// or runs within it.
+func (s *outputStore) set(ctx context.Context, node string, value interface{}) error {
+	info, _ := GetTaskInfo(ctx)
+	if !s.nodes[node] || len(info.Path) <= len(s.path) || info.Path[len(s.path)] != node {
+		if s.parent != nil {
+			return s.parent.set(ctx, node, value)
+		}
+		return fmt.Errorf("%w: %s is not set by its producer", ErrOutputUnknown, node)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[node] = value
+	return nil
+}
+
+// get returns the value of the output of node.
+func (s *outputStore) get(node string) (interface{}, error) {
+	if s == nil {
+		return nil, fmt.Errorf("%w: %s is read outside of a DAG", ErrOutputUnknown, node)
+	}
+	if !s.nodes[node] {
+		return s.parent.get(node)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.done[node] {
+		return nil, fmt.Errorf("%w: %s did not succeed", ErrOutputNotReady, node)
+	}
+	value, ok := s.values[node]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s did not set its output", ErrOutputNotReady, node)
+	}
+	return value, nil
+}
+
+// lookup returns the value of the output of node, visible or not.
+func (s *outputStore) lookup(node string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[node]
+	return value, ok
+}
+
+// restore sets the value of the output of node, as found in a cache.
+func (s *outputStore) restore(node string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[node] = value
+}
+
+// complete makes the output of node visible.
+func (s *outputStore) complete(node string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done[node] = true
+}
@@ -0,0 +1,150 @@
+package exe
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+func TestOutput(t *testing.T) {
+	var (
+		fetched = NewOutput[int]("fetch")
+		doubled = NewOutput[int]("double")
+	)
+	fetch := T(func(ctx context.Context) error {
+		return fetched.Set(ctx, 21)
+	})
+	double := T(func(ctx context.Context) error {
+		v, err := fetched.Get(ctx)
+		if err != nil {
+			return err
+		}
+		return doubled.Set(ctx, v*2)
+	})
+
+	t.Run("pass values", func(t *testing.T) {
+		var got int
+		b := NewDAGBuilder()
+		b.AddNodeWithOptions("fetch", NewSerial(fetch), Produces(fetched))
+		b.AddNodeWithOptions("double", double, DependsOn("fetch"), Consumes(fetched), Produces(doubled))
+		b.AddNodeWithOptions("print", NewSerial(T(func(ctx context.Context) error {
+			// nested DAGs read the outputs of the enclosing ones
+			inner := NewDAGBuilder()
+			inner.AddNode("read", T(func(ctx context.Context) error {
+				var err error
+				got, err = doubled.Get(ctx)
+				return err
+			}))
+			d, err := inner.Build()
+			if err != nil {
+				return err
+			}
+			return d.Do(ctx)
+		})), DependsOn("double"), Consumes(doubled))
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		if err = d.Do(context.Background()); err != nil || got != 42 {
+			t.Errorf("DAG.Do() error = %v, got %v, want 42", err, got)
+		}
+	})
+
+	t.Run("not ready", func(t *testing.T) {
+		var getErr error
+		b := NewDAGBuilder()
+		b.AddNodeWithOptions("fetch", T(func(ctx context.Context) error {
+			return errTest
+		}), Produces(fetched), WithFailurePolicy(IgnoreFailure))
+		b.AddNodeWithOptions("double", T(func(ctx context.Context) error {
+			_, getErr = fetched.Get(ctx)
+			return nil
+		}), DependsOn("fetch"), Consumes(fetched))
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		if err = d.Do(context.Background()); err != nil || !errors.Is(getErr, ErrOutputNotReady) {
+			t.Errorf("DAG.Do() error = %v, Output.Get() error = %v", err, getErr)
+		}
+	})
+
+	t.Run("set by another node", func(t *testing.T) {
+		b := NewDAGBuilder()
+		b.AddNodeWithOptions("fetch", T(func(ctx context.Context) error {
+			return nil
+		}), Produces(fetched))
+		b.AddNode("thief", fetch)
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		if err = d.Do(context.Background()); !errors.Is(err, ErrOutputUnknown) {
+			t.Errorf("DAG.Do() error = %v, want %v", err, ErrOutputUnknown)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		nodes   map[string][]NodeOption
+		wantErr error
+	}{
+		{
+			name: "type mismatch",
+			nodes: map[string][]NodeOption{
+				"fetch":  {Produces(fetched)},
+				"double": {DependsOn("fetch"), Consumes(NewOutput[string]("fetch"))},
+			},
+			wantErr: ErrOutputType,
+		},
+		{
+			name: "unknown producer",
+			nodes: map[string][]NodeOption{
+				"fetch":  nil,
+				"double": {DependsOn("fetch"), Consumes(fetched)},
+			},
+			wantErr: ErrOutputUnknown,
+		},
+		{
+			name: "misnamed producer",
+			nodes: map[string][]NodeOption{
+				"fetch":  {Produces(doubled)},
+				"double": {DependsOn("fetch")},
+			},
+			wantErr: ErrOutputUnknown,
+		},
+		{
+			name: "not downstream",
+			nodes: map[string][]NodeOption{
+				"fetch":  {Produces(fetched)},
+				"double": {Before("fetch"), Consumes(fetched)},
+			},
+			wantErr: ErrOutputOrder,
+		},
+		{
+			name: "transitively downstream",
+			nodes: map[string][]NodeOption{
+				"fetch":  {Produces(fetched)},
+				"middle": {DependsOn("fetch")},
+				"double": {DependsOn("middle"), Consumes(fetched)},
+			},
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewDAGBuilder()
+			for name, opts := range tt.nodes {
+				b.AddNodeWithOptions(name, concept.Task(nil), opts...)
+			}
+			if _, err := b.Build(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("DAG.Build() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
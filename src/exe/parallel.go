@@ -2,8 +2,6 @@ package exe
 
 import (
 	"context"
-	"fmt"
-	"runtime/debug"
 
 	"github.com/SakuraSa/ge/src/concept"
 )
@@ -13,37 +11,94 @@ var (
 )
 
 // Parallel is a task that executes its children concurrently.
+//
+// The children run against a context derived from the caller's one, which is
+// cancelled as soon as Do returns or, unless the mode is CollectAll, as soon
+// as a child fails.
 type Parallel struct {
 	children []concept.Task
+	mode     FailureMode
+	limiter  *Limiter
 }
 
-func (p Parallel) Do(ctx context.Context) error {
+func (p Parallel) Do(ctx context.Context) (err error) {
+	emitRun(ctx, RunStarted, ExecutorParallel, nil)
+	defer func() {
+		emitRun(ctx, RunFinished, ExecutorParallel, err)
+	}()
+
+	if len(p.children) == 0 {
+		return nil
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	errs := make(chan error, len(p.children))
-	var aops = GetAOP(ctx)
-	for _, child := range p.children {
-		f := aops.Apply(child.Do)
-		go func(child concept.Task) {
-			defer func() {
-				if e := recover(); e != nil {
-					errs <- fmt.Errorf("task %s panic: %v\n%s", child, e, debug.Stack())
-				}
-			}()
-			errs <- f(ctx)
-		}(child)
+	for i, child := range p.children {
+		info := newTaskInfo(ctx, ExecutorParallel, i, childMeta(child, i))
+		go func(info concept.TaskInfo, child concept.Task, t *ticket) {
+			errs <- run(runCtx, p.limiter, t, info, child)
+		}(info, child, p.limiter.reserve(0))
 	}
-	for range p.children {
+
+	var (
+		first     error
+		collected []error
+	)
+	for pending := len(p.children); pending > 0; pending-- {
 		select {
 		case <-ctx.Done():
+			cancel()
+			if p.mode != FailFast {
+				drain(errs, pending)
+			}
 			return ctx.Err()
 		case err := <-errs:
-			if err != nil {
+			if err == nil {
+				continue
+			}
+			switch p.mode {
+			case WaitAll:
+				if first == nil {
+					first = err
+					cancel()
+				}
+			case CollectAll:
+				collected = append(collected, err)
+			default:
 				return err
 			}
 		}
 	}
-	return nil
+	if first != nil {
+		return first
+	}
+	return joinErrors(collected)
+}
+
+// WithMode returns a copy of p that reacts to failures according to mode.
+func (p Parallel) WithMode(mode FailureMode) Parallel {
+	p.mode = mode
+	return p
+}
+
+// WithLimiter returns a copy of p that runs at most as many children at a
+// time as limiter allows; children beyond that wait in its queue.
+func (p Parallel) WithLimiter(limiter *Limiter) Parallel {
+	p.limiter = limiter
+	return p
 }
 
+func (Parallel) executor() {}
+
 func NewParallel(children ...concept.Task) Parallel {
 	return Parallel{children: children}
 }
+
+// drain waits for n more results on errs and discards them.
+func drain(errs <-chan error, n int) {
+	for ; n > 0; n-- {
+		<-errs
+	}
+}
@@ -2,7 +2,9 @@ package exe
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -133,4 +135,76 @@ func TestParallel(t *testing.T) {
 			t.Errorf("Parallel.Do() error = %v, wantErr %v", err, context.Canceled)
 		}
 	})
+	t.Run("fail-fast cancels siblings", func(t *testing.T) {
+		cancelled := make(chan struct{})
+		s := NewParallel(
+			T(func(ctx context.Context) error {
+				return fmt.Errorf("error")
+			}),
+			T(func(ctx context.Context) error {
+				<-ctx.Done()
+				close(cancelled)
+				return ctx.Err()
+			}),
+		)
+		if err := s.Do(context.Background()); err == nil {
+			t.Errorf("Parallel.Do() error = %v, wantErr %v", err, true)
+		}
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Errorf("Parallel.Do() sibling was not cancelled")
+		}
+	})
+
+	t.Run("wait-all drains children", func(t *testing.T) {
+		var finished bool
+		s := NewParallel(
+			T(func(ctx context.Context) error {
+				return errTest
+			}),
+			T(func(ctx context.Context) error {
+				<-ctx.Done()
+				time.Sleep(time.Millisecond * 10)
+				finished = true
+				return ctx.Err()
+			}),
+		).WithMode(WaitAll)
+		err := s.Do(context.Background())
+		if !errors.Is(err, errTest) {
+			t.Errorf("Parallel.Do() error = %v, want %v", err, errTest)
+		}
+		if !finished {
+			t.Errorf("Parallel.Do() returned before children finished")
+		}
+	})
+
+	t.Run("collect-all reports every error", func(t *testing.T) {
+		var finished bool
+		s := NewParallel(
+			T(func(ctx context.Context) error {
+				return fmt.Errorf("error 1")
+			}),
+			T(func(ctx context.Context) error {
+				time.Sleep(time.Millisecond * 10)
+				if ctx.Err() == nil {
+					finished = true
+				}
+				return fmt.Errorf("error 2")
+			}),
+		).WithMode(CollectAll)
+		err := s.Do(context.Background())
+		if err == nil {
+			t.Errorf("Parallel.Do() error = %v, wantErr %v", err, true)
+			return
+		}
+		for _, want := range []string{"error 1", "error 2"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Parallel.Do() error = %v, want it to contain %v", err, want)
+			}
+		}
+		if !finished {
+			t.Errorf("Parallel.Do() cancelled a child in collect-all mode")
+		}
+	})
 }
@@ -0,0 +1,43 @@
+package exe
+
+import (
+	"sort"
+)
+
+// Plan returns the names of the nodes of d by stage: every node is in the
+// stage after the last of the nodes it waits for, so the nodes of a stage
+// may run at the same time once the previous stages are done. Names are
+// sorted within a stage.
+func (d DAG) Plan() [][]string {
+	var (
+		stage = make([]int, len(d.nodes))
+		conds = d.getConds()
+		ready []int
+		plan  [][]string
+	)
+	for i, cond := range conds {
+		if cond == 0 {
+			ready = append(ready, i)
+		}
+	}
+	for len(ready) > 0 {
+		index := ready[0]
+		ready = ready[1:]
+		for len(plan) <= stage[index] {
+			plan = append(plan, nil)
+		}
+		plan[stage[index]] = append(plan[stage[index]], d.name(index))
+		for _, next := range d.edges[index] {
+			if stage[next] < stage[index]+1 {
+				stage[next] = stage[index] + 1
+			}
+			if conds[next]--; conds[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+	for _, names := range plan {
+		sort.Strings(names)
+	}
+	return plan
+}
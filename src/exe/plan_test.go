@@ -0,0 +1,29 @@
+package exe
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func planDAG(t *testing.T) DAG {
+	noop := T(func(ctx context.Context) error { return nil })
+	b := NewDAGBuilder()
+	b.AddNode("extract-a", noop)
+	b.AddNode("extract-b", noop)
+	b.AddNodeWithOptions("transform", noop, DependsOn("extract-a"))
+	b.AddNodeWithOptions("load", noop, DependsOn("transform", "extract-b"))
+	b.AddNodeWithOptions("lint", noop)
+	d, err := b.Build()
+	if err != nil {
+		t.Fatalf("DAG.Build() error = %v", err)
+	}
+	return d
+}
+
+func TestDAG_Plan(t *testing.T) {
+	got := fmt.Sprint(planDAG(t).Plan())
+	if want := "[[extract-a extract-b lint] [transform] [load]]"; got != want {
+		t.Errorf("DAG.Plan() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,197 @@
+package exe
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// NodeReport is the state of a DAG node in a run.
+type NodeReport struct {
+	Name  string
+	State NodeState
+	// Start and End are when the node started and returned; they are zero
+	// for the nodes that did not run.
+	Start time.Time
+	End   time.Time
+	// Attempts is the number of times the node was run, retries included.
+	Attempts int
+	// Err is the error the node returned, if any.
+	Err error
+	// Resumed is true for the nodes that did not run again, as the run
+	// they succeeded in was resumed.
+	Resumed bool
+	// UpToDate is true for the nodes that did not run again, as their
+	// inputs did not change since they last succeeded.
+	UpToDate bool
+}
+
+// Duration returns how long the node ran for, or has been running for.
+func (n NodeReport) Duration() time.Duration {
+	switch {
+	case n.Start.IsZero():
+		return 0
+	case n.End.IsZero():
+		return time.Since(n.Start)
+	default:
+		return n.End.Sub(n.Start)
+	}
+}
+
+// RunReport is the state of a DAG run and of its nodes, in the order of
+// DAG.Nodes.
+type RunReport struct {
+	Start time.Time
+	// End is when the run returned; it is zero while the run is in
+	// progress.
+	End   time.Time
+	Nodes []NodeReport
+	// Err is the error the run returned.
+	Err error
+}
+
+// Done reports whether the run is over.
+func (r RunReport) Done() bool {
+	return !r.End.IsZero()
+}
+
+// Node returns the report of the node called name.
+func (r RunReport) Node(name string) (NodeReport, bool) {
+	for _, node := range r.Nodes {
+		if node.Name == name {
+			return node, true
+		}
+	}
+	return NodeReport{}, false
+}
+
+// Count returns the number of nodes in state.
+func (r RunReport) Count(state NodeState) int {
+	n := 0
+	for _, node := range r.Nodes {
+		if node.State == state {
+			n++
+		}
+	}
+	return n
+}
+
+// Run is a DAG run started by DAG.Start.
+type Run struct {
+	report *runReport
+	done   chan struct{}
+	err    error
+}
+
+// Start starts running d in the background, as Do would.
+func (d DAG) Start(ctx context.Context) *Run {
+	run := &Run{
+		report: newRunReport(d),
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(run.done)
+		run.err = d.do(ctx, run.report)
+	}()
+	return run
+}
+
+// Done returns a channel closed when the run is over.
+func (r *Run) Done() <-chan struct{} {
+	return r.done
+}
+
+// Wait waits for the run to be over and returns its error, as Do would.
+func (r *Run) Wait() error {
+	<-r.done
+	return r.err
+}
+
+// Report returns a snapshot of the state of the run, which is final once the
+// run is over.
+func (r *Run) Report() RunReport {
+	return r.report.snapshot()
+}
+
+// runReport is the live report of a run, shared by its goroutines.
+type runReport struct {
+	mu     sync.Mutex
+	report RunReport
+}
+
+func newRunReport(d DAG) *runReport {
+	nodes := make([]NodeReport, len(d.nodes))
+	for i := range nodes {
+		nodes[i].Name = d.name(i)
+	}
+	return &runReport{report: RunReport{Start: time.Now(), Nodes: nodes}}
+}
+
+// snapshot returns a copy of the report.
+func (r *runReport) snapshot() RunReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := r.report
+	report.Nodes = append([]NodeReport(nil), r.report.Nodes...)
+	return report
+}
+
+// started records that the index-th node started, unless the run is over.
+func (r *runReport) started(index int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.report.Done() {
+		node := &r.report.Nodes[index]
+		node.State = Running
+		node.Start = time.Now()
+	}
+}
+
+// resumed records that the index-th node succeeded in the run resumed.
+func (r *runReport) resumed(index int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	node := &r.report.Nodes[index]
+	node.State = Succeeded
+	node.Resumed = true
+}
+
+// upToDate records that the index-th node succeeded without running, as it
+// was up to date.
+func (r *runReport) upToDate(index int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	node := &r.report.Nodes[index]
+	node.State = Succeeded
+	node.UpToDate = true
+}
+
+// settled records the final state of the index-th node.
+func (r *runReport) settled(index int, state NodeState, attempts int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	node := &r.report.Nodes[index]
+	node.State = state
+	node.Attempts = attempts
+	node.Err = err
+	if !node.Start.IsZero() {
+		node.End = time.Now()
+	}
+}
+
+// finished records the end of the run. Nodes abandoned by the run are
+// reported as cancelled.
+func (r *runReport) finished(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.End = time.Now()
+	r.report.Err = err
+	for i := range r.report.Nodes {
+		if node := &r.report.Nodes[i]; !node.State.Done() {
+			node.State = Cancelled
+			if !node.Start.IsZero() {
+				node.End = r.report.End
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package exe
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDAG_Start(t *testing.T) {
+	t.Run("live snapshot", func(t *testing.T) {
+		release := make(chan struct{})
+		started := make(chan struct{})
+		b := NewDAGBuilder()
+		b.AddNode("slow", T(func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		}), "next")
+		b.AddNode("next", T(func(ctx context.Context) error {
+			return nil
+		}))
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+
+		run := d.Start(context.Background())
+		<-started
+		report := run.Report()
+		if report.Done() {
+			t.Errorf("Run.Report() is done while the run is in progress")
+		}
+		if slow, _ := report.Node("slow"); slow.State != Running || slow.Start.IsZero() {
+			t.Errorf("Run.Report() slow = %+v, want running", slow)
+		}
+		if next, _ := report.Node("next"); next.State != Pending {
+			t.Errorf("Run.Report() next = %+v, want pending", next)
+		}
+
+		close(release)
+		if err := run.Wait(); err != nil {
+			t.Errorf("Run.Wait() error = %v", err)
+		}
+		report = run.Report()
+		if !report.Done() || report.Count(Succeeded) != 2 {
+			t.Errorf("Run.Report() = %+v, want 2 succeeded nodes", report)
+		}
+		for _, node := range report.Nodes {
+			if node.Attempts != 1 || node.End.Before(node.Start) {
+				t.Errorf("Run.Report() %s = %+v", node.Name, node)
+			}
+		}
+	})
+
+	t.Run("final states", func(t *testing.T) {
+		b := NewDAGBuilder()
+		b.AddNodeWithOptions("flaky", T(func(ctx context.Context) error {
+			return errTest
+		}), Before("blocked", "notify"), WithRetry(NewRetry(RetryMaxAttempts(2))))
+		b.AddNode("blocked", T(func(ctx context.Context) error {
+			return nil
+		}))
+		b.AddNodeWithOptions("notify", T(func(ctx context.Context) error {
+			return nil
+		}), WithTrigger(OneSuccess))
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+
+		run := d.WithMode(CollectAll).Start(context.Background())
+		if err := run.Wait(); !errors.Is(err, errTest) {
+			t.Errorf("Run.Wait() error = %v, want %v", err, errTest)
+		}
+		report := run.Report()
+		if !errors.Is(report.Err, errTest) {
+			t.Errorf("RunReport.Err = %v, want %v", report.Err, errTest)
+		}
+		want := map[string]NodeState{"flaky": Failed, "blocked": UpstreamFailed, "notify": UpstreamFailed}
+		for name, state := range want {
+			node, ok := report.Node(name)
+			if !ok || node.State != state {
+				t.Errorf("RunReport.Node(%q) = %+v, want %v", name, node, state)
+			}
+		}
+		if flaky, _ := report.Node("flaky"); flaky.Attempts != 2 || !errors.Is(flaky.Err, errTest) {
+			t.Errorf("RunReport.Node(%q) = %+v, want 2 failed attempts", "flaky", flaky)
+		}
+		if blocked, _ := report.Node("blocked"); !blocked.Start.IsZero() || blocked.Duration() != 0 {
+			t.Errorf("RunReport.Node(%q) = %+v, want no start", "blocked", blocked)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		b := NewDAGBuilder()
+		b.AddNode("wait", T(func(ctx context.Context) error {
+			cancel()
+			<-ctx.Done()
+			return ctx.Err()
+		}), "never")
+		b.AddNode("never", T(func(ctx context.Context) error {
+			return nil
+		}))
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+
+		run := d.Start(ctx)
+		select {
+		case <-run.Done():
+		case <-time.After(time.Second):
+			t.Errorf("Run.Done() is not closed")
+			return
+		}
+		if err := run.Wait(); !errors.Is(err, context.Canceled) {
+			t.Errorf("Run.Wait() error = %v, want %v", err, context.Canceled)
+		}
+		if n := run.Report().Count(Cancelled); n != 2 {
+			t.Errorf("RunReport.Count(Cancelled) = %d, want 2", n)
+		}
+	})
+}
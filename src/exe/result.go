@@ -0,0 +1,164 @@
+package exe
+
+import (
+	"context"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+var (
+	_ concept.TaskOf[[]int] = ParallelOf[int]{}
+	_ concept.TaskOf[int]   = boundPipeline[int]{}
+)
+
+// ParallelOf is a task that executes its children concurrently, like
+// Parallel, and returns their results in the order of the children.
+type ParallelOf[T any] struct {
+	children []concept.TaskOf[T]
+	parallel Parallel
+}
+
+// Do returns the results of the children. Children that failed or did not
+// run leave the zero value of T in their place.
+//
+// Do always waits for the children before returning their results: in the
+// FailFast mode, it runs them as in the WaitAll one, which also cancels them
+// on the first failure.
+func (p ParallelOf[T]) Do(ctx context.Context) ([]T, error) {
+	results := make([]T, len(p.children))
+	tasks := make([]concept.Task, len(p.children))
+	for i, child := range p.children {
+		tasks[i] = collect(child, &results[i])
+	}
+	parallel := p.parallel
+	parallel.children = tasks
+	if parallel.mode == FailFast {
+		parallel.mode = WaitAll
+	}
+	return results, parallel.Do(ctx)
+}
+
+// WithMode returns a copy of p that reacts to failures according to mode.
+func (p ParallelOf[T]) WithMode(mode FailureMode) ParallelOf[T] {
+	p.parallel = p.parallel.WithMode(mode)
+	return p
+}
+
+// WithLimiter returns a copy of p that runs at most as many children at a
+// time as limiter allows.
+func (p ParallelOf[T]) WithLimiter(limiter *Limiter) ParallelOf[T] {
+	p.parallel = p.parallel.WithLimiter(limiter)
+	return p
+}
+
+func (ParallelOf[T]) executor() {}
+
+func NewParallelOf[T any](children ...concept.TaskOf[T]) ParallelOf[T] {
+	return ParallelOf[T]{children: children}
+}
+
+// Step is a stage of a Pipeline, which transforms the value of the previous
+// one.
+type Step[T any] func(ctx context.Context, in T) (T, error)
+
+// Pipeline is a task that executes its steps in order, like Serial, feeding
+// each step the result of the previous one.
+type Pipeline[T any] struct {
+	steps  []Step[T]
+	serial Serial
+}
+
+// Do runs the steps on in, and returns the result of the last one. If a step
+// fails, Do returns the result of the last step that succeeded.
+func (p Pipeline[T]) Do(ctx context.Context, in T) (T, error) {
+	value := in
+	tasks := make([]concept.Task, len(p.steps))
+	for i, step := range p.steps {
+		step := step
+		tasks[i] = concept.TaskFunc(func(ctx context.Context) error {
+			out, err := step(ctx, value)
+			if err != nil {
+				return err
+			}
+			value = out
+			return nil
+		})
+	}
+	serial := p.serial
+	serial.children = tasks
+	err := serial.Do(ctx)
+	return value, err
+}
+
+// Bind returns a task running p on in.
+func (p Pipeline[T]) Bind(in T) concept.TaskOf[T] {
+	return boundPipeline[T]{pipeline: p, in: in}
+}
+
+// WithBudget returns a copy of p that splits the time left before the
+// deadline of its context between its steps, like Serial.WithBudget.
+func (p Pipeline[T]) WithBudget(expected ...time.Duration) Pipeline[T] {
+	// the budget is sized after the children, which are only built by Do
+	p.serial.children = make([]concept.Task, len(p.steps))
+	p.serial = p.serial.WithBudget(expected...)
+	return p
+}
+
+func (Pipeline[T]) executor() {}
+
+func NewPipeline[T any](steps ...Step[T]) Pipeline[T] {
+	return Pipeline[T]{steps: steps}
+}
+
+// boundPipeline is a Pipeline bound to its input.
+type boundPipeline[T any] struct {
+	pipeline Pipeline[T]
+	in       T
+}
+
+func (p boundPipeline[T]) Do(ctx context.Context) (T, error) {
+	return p.pipeline.Do(ctx, p.in)
+}
+
+func (boundPipeline[T]) executor() {}
+
+// Produce adapts a TaskOf to a task setting o to its result. It exposes the
+// same metadata.
+func Produce[T any](o Output[T], task concept.TaskOf[T]) concept.Task {
+	return collectWith(task, func(ctx context.Context, value T) error {
+		return o.Set(ctx, value)
+	})
+}
+
+// collect adapts a TaskOf to a task storing its result in result.
+func collect[T any](task concept.TaskOf[T], result *T) concept.Task {
+	return collectWith(task, func(_ context.Context, value T) error {
+		*result = value
+		return nil
+	})
+}
+
+// collectWith adapts a TaskOf to a task handing its result to sink.
+func collectWith[T any](task concept.TaskOf[T], sink func(context.Context, T) error) concept.Task {
+	return concept.WithMeta(resultTask[T]{task: task, sink: sink}, concept.MetaOf(task))
+}
+
+// resultTask is a TaskOf adapted to a Task.
+type resultTask[T any] struct {
+	task concept.TaskOf[T]
+	sink func(context.Context, T) error
+}
+
+func (t resultTask[T]) Do(ctx context.Context) error {
+	value, err := t.task.Do(ctx)
+	if err != nil {
+		return err
+	}
+	return t.sink(ctx, value)
+}
+
+// wrapped returns the adapted task.
+func (t resultTask[T]) wrapped() interface{} {
+	return t.task
+}
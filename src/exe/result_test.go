@@ -0,0 +1,140 @@
+package exe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+func TestParallelOf(t *testing.T) {
+	square := func(n int) concept.TaskOf[int] {
+		return concept.TaskFuncOf[int](func(ctx context.Context) (int, error) {
+			time.Sleep(time.Millisecond * time.Duration(5-n))
+			return n * n, nil
+		})
+	}
+
+	t.Run("results", func(t *testing.T) {
+		got, err := NewParallelOf(square(1), square(2), square(3)).Do(context.Background())
+		if err != nil || fmt.Sprint(got) != "[1 4 9]" {
+			t.Errorf("ParallelOf.Do() = %v, %v, want [1 4 9]", got, err)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var failing concept.TaskOf[int] = concept.TaskFuncOf[int](func(ctx context.Context) (int, error) {
+			return 0, errTest
+		})
+		got, err := NewParallelOf(square(1), failing, square(3)).WithMode(CollectAll).Do(context.Background())
+		if !errors.Is(err, errTest) || fmt.Sprint(got) != "[1 0 9]" {
+			t.Errorf("ParallelOf.Do() = %v, %v", got, err)
+		}
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		var failing concept.TaskOf[int] = concept.TaskFuncOf[int](func(ctx context.Context) (int, error) {
+			return 0, errTest
+		})
+		var slow concept.TaskOf[int] = concept.TaskFuncOf[int](func(ctx context.Context) (int, error) {
+			time.Sleep(time.Millisecond * 10)
+			return 1, nil
+		})
+		got, err := NewParallelOf(failing, slow).Do(context.Background())
+		returned := fmt.Sprint(got)
+		time.Sleep(time.Millisecond * 20)
+		if !errors.Is(err, errTest) || fmt.Sprint(got) != returned {
+			t.Errorf("ParallelOf.Do() = %v, %v, changed to %v after returning", returned, err, got)
+		}
+	})
+}
+
+func TestPipeline(t *testing.T) {
+	inc := func(ctx context.Context, in int) (int, error) {
+		return in + 1, nil
+	}
+	double := func(ctx context.Context, in int) (int, error) {
+		return in * 2, nil
+	}
+	fail := func(ctx context.Context, in int) (int, error) {
+		return 0, errTest
+	}
+
+	tests := []struct {
+		name    string
+		steps   []Step[int]
+		in      int
+		want    int
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			steps:   nil,
+			in:      1,
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name:    "normal",
+			steps:   []Step[int]{inc, double, inc},
+			in:      1,
+			want:    5,
+			wantErr: false,
+		},
+		{
+			name:    "error",
+			steps:   []Step[int]{inc, fail, double},
+			in:      1,
+			want:    2,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewPipeline(tt.steps...).Do(context.Background(), tt.in)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("Pipeline.Do() = %v, %v, want %v, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("bind", func(t *testing.T) {
+		p := NewPipeline[int](inc, double)
+		got, err := NewParallelOf(p.Bind(1), p.Bind(2)).Do(context.Background())
+		if err != nil || fmt.Sprint(got) != "[4 6]" {
+			t.Errorf("ParallelOf.Do() = %v, %v, want [4 6]", got, err)
+		}
+	})
+}
+
+func TestAdapters(t *testing.T) {
+	var ran bool
+	lifted := concept.Lift(T(func(ctx context.Context) error {
+		ran = true
+		return nil
+	}))
+	if _, err := lifted.Do(context.Background()); err != nil || !ran {
+		t.Errorf("Lift().Do() error = %v, ran = %v", err, ran)
+	}
+
+	count := NewOutput[string]("count")
+	b := NewDAGBuilder()
+	b.AddNodeWithOptions("count", Produce[string](count, concept.TaskFuncOf[string](func(ctx context.Context) (string, error) {
+		return strconv.Itoa(3), nil
+	})), Produces(count))
+	b.AddNodeWithOptions("check", concept.Discard[string](concept.TaskFuncOf[string](func(ctx context.Context) (string, error) {
+		return count.Get(ctx)
+	})), DependsOn("count"), Consumes(count))
+	d, err := b.Build()
+	if err != nil {
+		t.Errorf("DAG.Build() error = %v", err)
+		return
+	}
+	if err = d.Do(context.Background()); err != nil {
+		t.Errorf("DAG.Do() error = %v", err)
+	}
+}
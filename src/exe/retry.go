@@ -0,0 +1,214 @@
+package exe
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+var (
+	_ concept.AOP = Retry{}
+)
+
+// Backoff decides how long to wait before retrying a task.
+type Backoff interface {
+	// Delay returns how long to wait before the given attempt, starting at 2.
+	Delay(attempt int) time.Duration
+}
+
+// BackoffFunc is a function type that implements Backoff.
+type BackoffFunc func(attempt int) time.Duration
+
+// Delay calls b(attempt).
+func (b BackoffFunc) Delay(attempt int) time.Duration {
+	return b(attempt)
+}
+
+// FixedBackoff waits delay before every retry.
+func FixedBackoff(delay time.Duration) Backoff {
+	return BackoffFunc(func(int) time.Duration {
+		return delay
+	})
+}
+
+// ExponentialBackoff waits initial before the first retry and multiplies the
+// delay by multiplier for every following one, up to maxDelay if it is
+// positive.
+func ExponentialBackoff(initial, maxDelay time.Duration, multiplier float64) Backoff {
+	return BackoffFunc(func(attempt int) time.Duration {
+		delay := float64(initial) * math.Pow(multiplier, float64(attempt-2))
+		if maxDelay > 0 && delay > float64(maxDelay) {
+			return maxDelay
+		}
+		return time.Duration(delay)
+	})
+}
+
+// JitteredBackoff randomly spreads the delays of backoff by up to fraction of
+// their value in both directions.
+func JitteredBackoff(backoff Backoff, fraction float64) Backoff {
+	return BackoffFunc(func(attempt int) time.Duration {
+		delay := float64(backoff.Delay(attempt))
+		jittered := delay + delay*fraction*(2*random()-1)
+		if jittered < 0 {
+			return 0
+		}
+		return time.Duration(jittered)
+	})
+}
+
+var (
+	randomMu  sync.Mutex
+	randomSrc = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// random returns a pseudo-random number in [0.0,1.0).
+func random() float64 {
+	randomMu.Lock()
+	defer randomMu.Unlock()
+	return randomSrc.Float64()
+}
+
+// retryable reports whether err is worth retrying, which is the case of
+// every error but context cancellation. Deadlines are retried, as the one of
+// an attempt, set with WithTimeout, may expire while the task's is not done.
+func retryable(err error) bool {
+	return !errors.Is(err, context.Canceled)
+}
+
+// Retry is an AOP that runs a task again when it fails, unless the context of
+// the task is done or the task was cancelled.
+//
+// The attempt being run is reported to the task through GetTaskInfo and
+// GetAttempt, and every retry is emitted as a NodeRetried event.
+type Retry struct {
+	maxAttempts int
+	maxElapsed  time.Duration
+	backoff     Backoff
+	retryIf     func(error) bool
+}
+
+// RetryOption configures a Retry.
+type RetryOption func(*Retry)
+
+// NewRetry returns a Retry that, unless configured otherwise, makes up to 3
+// attempts without waiting between them, and retries every error but context
+// cancellation.
+func NewRetry(opts ...RetryOption) Retry {
+	r := Retry{
+		maxAttempts: 3,
+	}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return r
+}
+
+// RetryMaxAttempts limits the number of attempts, the first one included.
+// A limit of zero or less only leaves the other options to stop retrying.
+func RetryMaxAttempts(n int) RetryOption {
+	return func(r *Retry) {
+		r.maxAttempts = n
+	}
+}
+
+// RetryMaxElapsed stops retrying when the next attempt would start more than
+// d after the first one.
+func RetryMaxElapsed(d time.Duration) RetryOption {
+	return func(r *Retry) {
+		r.maxElapsed = d
+	}
+}
+
+// RetryBackoff sets how long to wait between attempts.
+func RetryBackoff(backoff Backoff) RetryOption {
+	return func(r *Retry) {
+		r.backoff = backoff
+	}
+}
+
+// RetryIf only retries the errors for which pred returns true. Context
+// cancellation is never retried.
+func RetryIf(pred func(error) bool) RetryOption {
+	return func(r *Retry) {
+		r.retryIf = pred
+	}
+}
+
+// RetryOn only retries errors matching one of targets, as reported by
+// errors.Is.
+func RetryOn(targets ...error) RetryOption {
+	return RetryIf(func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// RetryOnType only retries errors that have an E in their chain, as reported
+// by errors.As.
+func RetryOnType[E error]() RetryOption {
+	return RetryIf(func(err error) bool {
+		var target E
+		return errors.As(err, &target)
+	})
+}
+
+// Apply returns a TaskFunc that runs f until it succeeds or r gives up, and
+// returns the error of the last attempt.
+func (r Retry) Apply(f concept.TaskFunc) concept.TaskFunc {
+	return func(ctx context.Context) error {
+		var (
+			start   = time.Now()
+			info, _ = GetTaskInfo(ctx)
+		)
+		var err error
+		for attempt := 1; ; attempt++ {
+			info.Attempt = attempt
+			setAttempt(ctx, attempt)
+			if attempt > 1 {
+				emitNode(ctx, NodeRetried, info, Running, err)
+			}
+			err = f(SetTaskInfo(ctx, info))
+			if err == nil || ctx.Err() != nil || !retryable(err) || (r.retryIf != nil && !r.retryIf(err)) {
+				return err
+			}
+			if r.maxAttempts > 0 && attempt >= r.maxAttempts {
+				return err
+			}
+			var delay time.Duration
+			if r.backoff != nil {
+				delay = r.backoff.Delay(attempt + 1)
+			}
+			if r.maxElapsed > 0 && time.Since(start)+delay > r.maxElapsed {
+				return err
+			}
+			if !sleep(ctx, delay) {
+				return err
+			}
+		}
+	}
+}
+
+// sleep waits for d, and reports false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
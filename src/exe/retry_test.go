@@ -0,0 +1,224 @@
+package exe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string {
+	return "temporary"
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff Backoff
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{
+			name:    "fixed",
+			backoff: FixedBackoff(time.Second),
+			attempt: 5,
+			min:     time.Second,
+			max:     time.Second,
+		},
+		{
+			name:    "exponential first",
+			backoff: ExponentialBackoff(time.Second, time.Minute, 2),
+			attempt: 2,
+			min:     time.Second,
+			max:     time.Second,
+		},
+		{
+			name:    "exponential",
+			backoff: ExponentialBackoff(time.Second, time.Minute, 2),
+			attempt: 4,
+			min:     time.Second * 4,
+			max:     time.Second * 4,
+		},
+		{
+			name:    "exponential capped",
+			backoff: ExponentialBackoff(time.Second, time.Minute, 2),
+			attempt: 20,
+			min:     time.Minute,
+			max:     time.Minute,
+		},
+		{
+			name:    "jittered",
+			backoff: JitteredBackoff(FixedBackoff(time.Second), 0.5),
+			attempt: 2,
+			min:     time.Millisecond * 500,
+			max:     time.Millisecond * 1500,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.backoff.Delay(tt.attempt); got < tt.min || got > tt.max {
+				t.Errorf("Backoff.Delay() = %v, want between %v and %v", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	// failing returns a task failing with err until its n-th attempt, and the
+	// attempts it saw.
+	failing := func(n int, err error) (concept.Task, *[]int) {
+		var attempts []int
+		return T(func(ctx context.Context) error {
+			attempts = append(attempts, GetAttempt(ctx))
+			if len(attempts) < n {
+				return err
+			}
+			return nil
+		}), &attempts
+	}
+
+	tests := []struct {
+		name         string
+		retry        Retry
+		succeedAt    int
+		err          error
+		wantAttempts int
+		wantErr      bool
+	}{
+		{
+			name:         "success",
+			retry:        NewRetry(),
+			succeedAt:    2,
+			err:          errTest,
+			wantAttempts: 2,
+			wantErr:      false,
+		},
+		{
+			name:         "max attempts",
+			retry:        NewRetry(RetryMaxAttempts(4)),
+			succeedAt:    10,
+			err:          errTest,
+			wantAttempts: 4,
+			wantErr:      true,
+		},
+		// the third attempt starts after 100ms, leaving 25ms of slack before
+		// the limit, and a fourth one would start after 150ms
+		{
+			name:         "max elapsed",
+			retry:        NewRetry(RetryMaxAttempts(0), RetryBackoff(FixedBackoff(time.Millisecond*50)), RetryMaxElapsed(time.Millisecond*125)),
+			succeedAt:    10,
+			err:          errTest,
+			wantAttempts: 3,
+			wantErr:      true,
+		},
+		{
+			name:         "retry on",
+			retry:        NewRetry(RetryOn(errTest)),
+			succeedAt:    10,
+			err:          fmt.Errorf("other"),
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "retry on type",
+			retry:        NewRetry(RetryOnType[temporaryError]()),
+			succeedAt:    3,
+			err:          fmt.Errorf("wrapped: %w", temporaryError{}),
+			wantAttempts: 3,
+			wantErr:      false,
+		},
+		{
+			name:         "cancellation",
+			retry:        NewRetry(),
+			succeedAt:    10,
+			err:          context.Canceled,
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "cancellation with retry on",
+			retry:        NewRetry(RetryIf(func(error) bool { return true })),
+			succeedAt:    10,
+			err:          fmt.Errorf("wrapped: %w", context.Canceled),
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "attempt timeout",
+			retry:        NewRetry(),
+			succeedAt:    3,
+			err:          context.DeadlineExceeded,
+			wantAttempts: 3,
+			wantErr:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, attempts := failing(tt.succeedAt, tt.err)
+			err := NewSerial(task).Do(SetAOP(context.Background(), tt.retry))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Serial.Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(*attempts) != tt.wantAttempts {
+				t.Errorf("Serial.Do() made %d attempts, want %d", len(*attempts), tt.wantAttempts)
+			}
+			for i, attempt := range *attempts {
+				if attempt != i+1 {
+					t.Errorf("GetAttempt() = %v, want %v", attempt, i+1)
+				}
+			}
+			var te *TaskError
+			if tt.wantErr && (!errors.As(err, &te) || te.Attempt != tt.wantAttempts) {
+				t.Errorf("Serial.Do() error = %+v, want it to report attempt %d", te, tt.wantAttempts)
+			}
+		})
+	}
+
+	t.Run("dag node", func(t *testing.T) {
+		task, attempts := failing(3, errTest)
+		b := NewDAGBuilder()
+		b.AddNode("retried", WithAOP(task, NewRetry()))
+		d, err := b.Build()
+		if err != nil {
+			t.Errorf("DAG.Build() error = %v", err)
+			return
+		}
+		if err = d.Do(context.Background()); err != nil || len(*attempts) != 3 {
+			t.Errorf("DAG.Do() error = %v after %d attempts", err, len(*attempts))
+		}
+	})
+
+	t.Run("ctx done while waiting", func(t *testing.T) {
+		task, attempts := failing(10, errTest)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+		defer cancel()
+		retried := WithAOP(task, NewRetry(RetryBackoff(FixedBackoff(time.Second))))
+		start := time.Now()
+		if err := retried.Do(ctx); !errors.Is(err, errTest) || time.Since(start) > time.Millisecond*500 {
+			t.Errorf("Do() error = %v after %v", err, time.Since(start))
+		}
+		if len(*attempts) != 1 {
+			t.Errorf("Do() made %d attempts, want 1", len(*attempts))
+		}
+	})
+
+	t.Run("ctx done while running", func(t *testing.T) {
+		var attempts int
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+		defer cancel()
+		retried := WithAOP(T(func(ctx context.Context) error {
+			attempts++
+			<-ctx.Done()
+			return ctx.Err()
+		}), NewRetry())
+		if err := retried.Do(ctx); !errors.Is(err, context.DeadlineExceeded) || attempts != 1 {
+			t.Errorf("Do() error = %v after %d attempts, want a single one", err, attempts)
+		}
+	})
+}
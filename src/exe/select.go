@@ -0,0 +1,102 @@
+package exe
+
+import (
+	"fmt"
+)
+
+var (
+	ErrUnknownNode = fmt.Errorf("unknown node in DAG")
+)
+
+// Select returns the DAG made of the targets and of the nodes they wait for,
+// directly or not, like make does for its targets. Targets are node names,
+// or regexps matching them, as the deps of DAGBuilder.
+func (d DAG) Select(targets ...string) (DAG, error) {
+	return d.selectClosure(targets, d.upstream())
+}
+
+// SelectDownstream returns the DAG made of the nodes given, by name or
+// regexp, and of the nodes waiting for them, directly or not.
+func (d DAG) SelectDownstream(nodes ...string) (DAG, error) {
+	return d.selectClosure(nodes, d.edges)
+}
+
+// selectClosure returns the DAG made of the nodes matching patterns and of
+// the nodes reachable from them through next. The result is checked by the
+// DAGCheckers, as a node may consume the output of one left out.
+func (d DAG) selectClosure(patterns []string, next [][]int) (DAG, error) {
+	index := make(map[string]int, len(d.nodes))
+	for i := range d.nodes {
+		index[d.name(i)] = i
+	}
+
+	var stack []int
+	for _, pattern := range patterns {
+		matches, err := resolveNodes(index, pattern)
+		if err != nil {
+			return DAG{}, err
+		}
+		if len(matches) == 0 {
+			return DAG{}, fmt.Errorf("%w: %s", ErrUnknownNode, pattern)
+		}
+		stack = append(stack, matches...)
+	}
+
+	keep := make([]bool, len(d.nodes))
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if keep[i] {
+			continue
+		}
+		keep[i] = true
+		stack = append(stack, next[i]...)
+	}
+
+	sub := d.subgraph(keep)
+	for _, f := range DAGCheckers {
+		if err := f(sub); err != nil {
+			return DAG{}, err
+		}
+	}
+	return sub, nil
+}
+
+// upstream returns, for every node, the nodes it waits for.
+func (d DAG) upstream() [][]int {
+	upstream := make([][]int, len(d.nodes))
+	for i, edges := range d.edges {
+		for _, next := range edges {
+			upstream[next] = append(upstream[next], i)
+		}
+	}
+	return upstream
+}
+
+// subgraph returns the DAG made of the nodes of d to keep, and of the edges
+// between them. It keeps the configuration of d.
+func (d DAG) subgraph(keep []bool) DAG {
+	sub := d
+	sub.nodes, sub.names, sub.options = nil, nil, nil
+	renumber := make([]int, len(d.nodes))
+	for i := range d.nodes {
+		if keep[i] {
+			renumber[i] = len(sub.nodes)
+			sub.nodes = append(sub.nodes, d.nodes[i])
+			sub.names = append(sub.names, d.name(i))
+			sub.options = append(sub.options, d.option(i))
+		}
+	}
+	sub.edges = make([][]int, len(sub.nodes))
+	for i, edges := range d.edges {
+		if !keep[i] {
+			continue
+		}
+		for _, next := range edges {
+			if keep[next] {
+				sub.edges[renumber[i]] = append(sub.edges[renumber[i]], renumber[next])
+			}
+		}
+	}
+	return sub
+}
@@ -0,0 +1,94 @@
+package exe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+func TestDAG_Select(t *testing.T) {
+	d := planDAG(t)
+	tests := []struct {
+		name       string
+		downstream bool
+		nodes      []string
+		want       string
+		wantErr    error
+	}{
+		{name: "target", nodes: []string{"transform"}, want: "[[extract-a] [transform]]"},
+		{name: "targets", nodes: []string{"transform", "lint"}, want: "[[extract-a lint] [transform]]"},
+		{name: "regexp", nodes: []string{"^l"}, want: "[[extract-a extract-b lint] [transform] [load]]"},
+		{name: "downstream", downstream: true, nodes: []string{"extract-a"}, want: "[[extract-a] [transform] [load]]"},
+		{name: "downstream leaf", downstream: true, nodes: []string{"load", "lint"}, want: "[[lint load]]"},
+		{name: "unknown", nodes: []string{"deploy"}, wantErr: ErrUnknownNode},
+		{name: "unknown downstream", downstream: true, nodes: []string{"^deploy-"}, wantErr: ErrUnknownNode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				sub DAG
+				err error
+			)
+			if tt.downstream {
+				sub, err = d.SelectDownstream(tt.nodes...)
+			} else {
+				sub, err = d.Select(tt.nodes...)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DAG.Select() error = %v, want %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got := fmt.Sprint(sub.Plan()); got != tt.want {
+				t.Errorf("DAG.Select() plan = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDAG_Select_run(t *testing.T) {
+	var ran []string
+	record := func(name string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+	out := NewOutput[int]("count")
+	b := NewDAGBuilder()
+	b.AddNodeWithOptions("count", Produce[int](out, concept.TaskFuncOf[int](func(ctx context.Context) (int, error) {
+		ran = append(ran, "count")
+		return 1, nil
+	})), Produces(out))
+	b.AddNodeWithOptions("report", T(record("report")), DependsOn("count"), Consumes(out))
+	b.AddNodeWithOptions("archive", T(record("archive")), DependsOn("report"))
+	d, err := b.Build()
+	if err != nil {
+		t.Errorf("DAG.Build() error = %v", err)
+		return
+	}
+
+	sub, err := d.WithMode(CollectAll).Select("report")
+	if err != nil {
+		t.Errorf("DAG.Select() error = %v", err)
+		return
+	}
+	if err := sub.Do(context.Background()); err != nil {
+		t.Errorf("DAG.Do() error = %v", err)
+	}
+	sort.Strings(ran)
+	if fmt.Sprint(ran) != "[count report]" {
+		t.Errorf("DAG.Do() ran %v", ran)
+	}
+
+	// report would read the output of a node left out
+	if _, err := d.SelectDownstream("report"); !errors.Is(err, ErrOutputUnknown) {
+		t.Errorf("DAG.SelectDownstream() error = %v, want %v", err, ErrOutputUnknown)
+	}
+}
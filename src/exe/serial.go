@@ -2,8 +2,7 @@ package exe
 
 import (
 	"context"
-	"fmt"
-	"runtime/debug"
+	"time"
 
 	"github.com/SakuraSa/ge/src/concept"
 )
@@ -15,30 +14,102 @@ var (
 // Serial is a task that executes its children in order.
 type Serial struct {
 	children []concept.Task
+	mode     FailureMode
+	budget   []time.Duration
 }
 
 func (s Serial) Do(ctx context.Context) (err error) {
-	var current concept.Task
+	emitRun(ctx, RunStarted, ExecutorSerial, nil)
 	defer func() {
-		if e := recover(); e != nil {
-			err = fmt.Errorf("task %s panic: %v\n%s", current, e, debug.Stack())
-		}
+		emitRun(ctx, RunFinished, ExecutorSerial, err)
 	}()
-	var aops = GetAOP(ctx)
-	for _, current = range s.children {
+
+	var errs []error
+	for i, current := range s.children {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			s.skip(ctx, i, Cancelled)
+			return joinErrors(append(errs, ctx.Err()))
 		default:
-			f := aops.Apply(current.Do)
-			if err = f(ctx); err != nil {
-				return
+			info := newTaskInfo(ctx, ExecutorSerial, i, childMeta(current, i))
+			childCtx, cancel := s.share(ctx, i)
+			err := run(childCtx, nil, nil, info, current)
+			cancel()
+			if err != nil {
+				if s.mode != CollectAll {
+					s.skip(ctx, i+1, Skipped)
+					return err
+				}
+				errs = append(errs, err)
 			}
 		}
 	}
-	return nil
+	return joinErrors(errs)
+}
+
+// skip emits NodeSkipped for the children from the index-th one on.
+func (s Serial) skip(ctx context.Context, index int, state NodeState) {
+	for i := index; i < len(s.children); i++ {
+		info := newTaskInfo(ctx, ExecutorSerial, i, childMeta(s.children[i], i))
+		emitNode(ctx, NodeSkipped, info, state, nil)
+	}
 }
 
+// WithMode returns a copy of s that reacts to failures according to mode.
+// With CollectAll the remaining children still run after a failure; the
+// other modes stop at the first one.
+func (s Serial) WithMode(mode FailureMode) Serial {
+	s.mode = mode
+	return s
+}
+
+// WithBudget returns a copy of s that splits the time left before the
+// deadline of its context between the children yet to run, in proportion to
+// their expected durations. Children without a positive expected duration
+// are expected to last as long as the average of the others, or all the same
+// if none is given.
+func (s Serial) WithBudget(expected ...time.Duration) Serial {
+	var (
+		sum   time.Duration
+		count int
+	)
+	for _, d := range expected {
+		if d > 0 {
+			sum += d
+			count++
+		}
+	}
+	mean := time.Second
+	if count > 0 {
+		mean = sum / time.Duration(count)
+	}
+	s.budget = make([]time.Duration, len(s.children))
+	for i := range s.budget {
+		s.budget[i] = mean
+		if i < len(expected) && expected[i] > 0 {
+			s.budget[i] = expected[i]
+		}
+	}
+	return s
+}
+
+// share returns the context of the index-th child, bounded to its share of
+// the budget if s has one.
+func (s Serial) share(ctx context.Context, index int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if s.budget == nil || !ok {
+		return ctx, func() {}
+	}
+	var left time.Duration
+	for _, d := range s.budget[index:] {
+		left += d
+	}
+	share := float64(time.Until(deadline)) * float64(s.budget[index]) / float64(left)
+	return context.WithTimeout(ctx, time.Duration(share))
+}
+
+func (Serial) executor() {}
+
 func NewSerial(children ...concept.Task) Serial {
 	return Serial{children: children}
 }
@@ -2,6 +2,7 @@ package exe
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -28,6 +29,10 @@ const (
 	testKey TestKeyType = "this_is_a_test_key"
 )
 
+var (
+	errTest = errors.New("test error")
+)
+
 type TestValue struct {
 	Values []string
 }
@@ -165,4 +170,74 @@ func TestSerial(t *testing.T) {
 			t.Errorf("Serial.Do() error = %v, wantErr %v", err, context.Canceled)
 		}
 	})
+	t.Run("collect-all", func(t *testing.T) {
+		var ran bool
+		s := NewSerial(
+			T(func(ctx context.Context) error {
+				return errTest
+			}),
+			T(func(ctx context.Context) error {
+				ran = true
+				return nil
+			}),
+			T(func(ctx context.Context) error {
+				return fmt.Errorf("error")
+			}),
+		).WithMode(CollectAll)
+		err := s.Do(context.Background())
+		if !ran {
+			t.Errorf("Serial.Do() stopped at the first error in collect-all mode")
+		}
+		var me *MultiError
+		if !errors.As(err, &me) || len(me.Errors) != 2 {
+			t.Errorf("Serial.Do() error = %v, want 2 collected errors", err)
+			return
+		}
+		if !errors.Is(err, errTest) {
+			t.Errorf("Serial.Do() error = %v, want it to match %v", err, errTest)
+		}
+		var te *TaskError
+		if !errors.As(me.Errors[1], &te) || te.Name != "2" {
+			t.Errorf("Serial.Do() error = %v, want it attributed to child 2", me.Errors[1])
+		}
+	})
+	t.Run("named children", func(t *testing.T) {
+		s := NewSerial(
+			T(func(ctx context.Context) error {
+				return nil
+			}),
+			concept.WithName(T(func(ctx context.Context) error {
+				return errTest
+			}), "named"),
+		)
+		err := s.Do(context.Background())
+		var te *TaskError
+		if !errors.As(err, &te) || te.Name != "named" {
+			t.Errorf("Serial.Do() error = %v, want it attributed to %v", err, "named")
+		}
+	})
+	t.Run("budget", func(t *testing.T) {
+		var left []time.Duration
+		record := T(func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			left = append(left, time.Until(deadline))
+			return nil
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		s := NewSerial(record, record, record).WithBudget(time.Millisecond, time.Millisecond*3)
+		if err := s.Do(ctx); err != nil {
+			t.Errorf("Serial.Do() error = %v", err)
+			return
+		}
+		// the third child is expected to last as long as the average of the
+		// others, and the children return at once, so the shares are 1/6 of
+		// the second, then 3/5 of it, then all of it.
+		want := []time.Duration{time.Second / 6, time.Second * 3 / 5, time.Second}
+		for i := range want {
+			if diff := left[i] - want[i]; diff > time.Millisecond*20 || diff < -time.Millisecond*20 {
+				t.Errorf("child %d got %v, want %v", i, left[i], want[i])
+			}
+		}
+	})
 }
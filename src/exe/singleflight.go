@@ -0,0 +1,150 @@
+package exe
+
+import (
+	"context"
+	"sync"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+var (
+	_ concept.AOP = Singleflight{}
+)
+
+// Singleflight is an AOP that de-duplicates concurrent runs of tasks with the
+// same key: while a task runs, the tasks with its key wait for it and share
+// its outcome instead of running. Tasks running after it completes run again;
+// combine Singleflight with Memo to keep outcomes for longer.
+//
+// A task that stops waiting because its context is done fails with the error
+// of its context. When the task it waits for is cancelled or panics, it runs
+// in its place rather than sharing that outcome.
+type Singleflight struct {
+	key   KeyFunc
+	group *flightGroup
+}
+
+// NewSingleflight returns a Singleflight identifying tasks by the key returned
+// by key, such as NameKey(nil). Copies of a Singleflight share its flights.
+func NewSingleflight(key KeyFunc) Singleflight {
+	return Singleflight{key: key, group: &flightGroup{flights: make(map[string]*flight)}}
+}
+
+// Apply returns a TaskFunc that runs f, unless a task with the same key is
+// running, in which case it returns the error of that task.
+func (s Singleflight) Apply(f concept.TaskFunc) concept.TaskFunc {
+	return func(ctx context.Context) error {
+		key := s.key(ctx)
+		if key == "" {
+			return f(ctx)
+		}
+		_, err := s.group.do(ctx, key, func(ctx context.Context) (interface{}, error) {
+			return nil, f(ctx)
+		})
+		return err
+	}
+}
+
+// SingleflightOf returns a TaskOf de-duplicated by s like the tasks it is
+// applied to, which shares the result of task as well. The result is shared
+// as is, so tasks returning slices, maps or pointers must not modify it.
+func SingleflightOf[T any](task concept.TaskOf[T], s Singleflight) concept.TaskOf[T] {
+	return singleflightTask[T]{task: task, flight: s}
+}
+
+// singleflightTask is a TaskOf de-duplicated by a Singleflight.
+type singleflightTask[T any] struct {
+	task   concept.TaskOf[T]
+	flight Singleflight
+}
+
+func (t singleflightTask[T]) Do(ctx context.Context) (T, error) {
+	key := t.flight.key(ctx)
+	if key == "" {
+		return t.task.Do(ctx)
+	}
+	value, err := t.flight.group.do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return t.task.Do(ctx)
+	})
+	typed, _ := value.(T)
+	return typed, err
+}
+
+// wrapped returns the de-duplicated task.
+func (t singleflightTask[T]) wrapped() interface{} {
+	return t.task
+}
+
+// flightGroup tracks the running tasks of a Singleflight by key.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a run of a task shared by the tasks with its key.
+type flight struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+	// abandoned is set when the outcome is not to be shared, as the task
+	// was cancelled or panicked.
+	abandoned bool
+	// waiters is the number of tasks waiting for the flight, guarded by the
+	// mutex of its group.
+	waiters int
+}
+
+// do runs fn under key, unless a run under key is in progress, in which case
+// it waits for it and returns its outcome.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	for {
+		g.mu.Lock()
+		if f, ok := g.flights[key]; ok {
+			f.waiters++
+			g.mu.Unlock()
+			var err error
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+			case <-f.done:
+			}
+			g.mu.Lock()
+			f.waiters--
+			g.mu.Unlock()
+			if err != nil {
+				return nil, err
+			}
+			if f.abandoned {
+				continue
+			}
+			return f.value, f.err
+		}
+		f := &flight{done: make(chan struct{}), abandoned: true}
+		g.flights[key] = f
+		g.mu.Unlock()
+		return g.run(ctx, key, f, fn)
+	}
+}
+
+// waiting returns the number of tasks waiting for the run under key.
+func (g *flightGroup) waiting(key string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if f, ok := g.flights[key]; ok {
+		return f.waiters
+	}
+	return 0
+}
+
+// run runs fn as f, and releases the tasks waiting for it even if fn panics.
+func (g *flightGroup) run(ctx context.Context, key string, f *flight, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	defer func() {
+		g.mu.Lock()
+		delete(g.flights, key)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+	f.value, f.err = fn(ctx)
+	f.abandoned = f.err != nil && ctx.Err() != nil
+	return f.value, f.err
+}
@@ -0,0 +1,283 @@
+package exe
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+// namedCtx returns a context describing a task with the given name.
+func namedCtx(name string) context.Context {
+	return SetTaskInfo(context.Background(), concept.TaskInfo{Meta: concept.Meta{Name: name}, Path: []string{name}})
+}
+
+// waitWaiting waits until n tasks wait for the task s runs for the task named
+// name.
+func waitWaiting(t *testing.T, s Singleflight, name string, n int) {
+	t.Helper()
+	key := s.key(namedCtx(name))
+	deadline := time.Now().Add(time.Second * 5)
+	for s.group.waiting(key) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d tasks waiting for %s, want %d", s.group.waiting(key), name, n)
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestSingleflight(t *testing.T) {
+	errShared := errors.New("shared")
+	tests := []struct {
+		name    string
+		names   []string
+		waiting map[string]int
+		runs    int32
+	}{
+		{
+			name:    "same key",
+			names:   []string{"task", "task", "task", "task"},
+			waiting: map[string]int{"task": 3},
+			runs:    1,
+		},
+		{
+			name:    "different keys",
+			names:   []string{"a", "b", "a", "b"},
+			waiting: map[string]int{"a": 1, "b": 1},
+			runs:    2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				flight  = NewSingleflight(NameKey(nil))
+				release = make(chan struct{})
+				runs    int32
+				wg      sync.WaitGroup
+				errs    = make([]error, len(tt.names))
+			)
+			task := flight.Apply(func(ctx context.Context) error {
+				atomic.AddInt32(&runs, 1)
+				<-release
+				return errShared
+			})
+			for i, name := range tt.names {
+				i, name := i, name
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs[i] = task(namedCtx(name))
+				}()
+			}
+			for name, n := range tt.waiting {
+				waitWaiting(t, flight, name, n)
+			}
+			close(release)
+			wg.Wait()
+			if runs != tt.runs {
+				t.Errorf("got %d runs, want %d", runs, tt.runs)
+			}
+			for i, err := range errs {
+				if err != errShared {
+					t.Errorf("task %d: got error %v, want %v", i, err, errShared)
+				}
+			}
+		})
+	}
+}
+
+func TestSingleflight_executors(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		runs    map[string]int
+		release chan struct{}
+	)
+	count := func(counter string) concept.Task {
+		return concept.TaskFunc(func(ctx context.Context) error {
+			mu.Lock()
+			runs[counter]++
+			mu.Unlock()
+			<-release
+			return nil
+		})
+	}
+	named := func(name string) concept.Task {
+		return concept.WithMeta(count(name), concept.Meta{Name: name})
+	}
+	dag := func(report string) concept.Task {
+		b := NewDAGBuilder()
+		b.AddNode("fetch", count("fetch"))
+		b.AddNodeWithOptions(report, count(report), DependsOn("fetch"))
+		d, err := b.Build()
+		if err != nil {
+			t.Fatalf("DAG.Build() error = %v", err)
+		}
+		return d
+	}
+	tests := []struct {
+		name    string
+		task    concept.Task
+		waiting map[string]int
+		want    map[string]int
+	}{
+		{
+			name:    "parallel branches",
+			task:    NewParallel(named("fetch"), named("fetch"), NewSerial(named("fetch"))),
+			waiting: map[string]int{"fetch": 2},
+			want:    map[string]int{"fetch": 1},
+		},
+		{
+			name:    "different names",
+			task:    NewParallel(named("a"), named("b"), named("a")),
+			waiting: map[string]int{"a": 1},
+			want:    map[string]int{"a": 1, "b": 1},
+		},
+		{
+			name: "unnamed",
+			task: NewParallel(NewSerial(count("work")), NewSerial(count("work"))),
+			want: map[string]int{"work": 2},
+		},
+		{
+			name:    "dags",
+			task:    NewParallel(dag("report-a"), dag("report-b")),
+			waiting: map[string]int{"fetch": 1},
+			want:    map[string]int{"fetch": 1, "report-a": 1, "report-b": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs, release = map[string]int{}, make(chan struct{})
+			flight := NewSingleflight(NameKey(nil))
+			done := make(chan error)
+			go func() {
+				done <- tt.task.Do(SetAOP(context.Background(), flight))
+			}()
+			for name, n := range tt.waiting {
+				waitWaiting(t, flight, name, n)
+			}
+			close(release)
+			if err := <-done; err != nil {
+				t.Errorf("Do() error = %v", err)
+			}
+			if !reflect.DeepEqual(runs, tt.want) {
+				t.Errorf("got runs %v, want %v", runs, tt.want)
+			}
+		})
+	}
+}
+
+func TestSingleflightCancelled(t *testing.T) {
+	tests := []struct {
+		name   string
+		cancel string
+		runs   int32
+		want   map[string]error
+	}{
+		{
+			name:   "waiter",
+			cancel: "waiter",
+			runs:   1,
+			want:   map[string]error{"leader": nil, "waiter": context.Canceled},
+		},
+		{
+			name:   "leader",
+			cancel: "leader",
+			runs:   2,
+			want:   map[string]error{"leader": context.Canceled, "waiter": nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				flight  = NewSingleflight(NameKey(nil))
+				started = make(chan struct{}, 2)
+				release = make(chan struct{})
+				runs    int32
+				mu      sync.Mutex
+				errs    = make(map[string]error)
+				done    = make(map[string]chan struct{})
+			)
+			task := flight.Apply(func(ctx context.Context) error {
+				atomic.AddInt32(&runs, 1)
+				started <- struct{}{}
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-release:
+					return nil
+				}
+			})
+			ctx, cancel := context.WithCancel(namedCtx("task"))
+			defer cancel()
+			start := func(name string) {
+				taskCtx := namedCtx("task")
+				if name == tt.cancel {
+					taskCtx = ctx
+				}
+				finished := make(chan struct{})
+				done[name] = finished
+				go func() {
+					defer close(finished)
+					err := task(taskCtx)
+					mu.Lock()
+					errs[name] = err
+					mu.Unlock()
+				}()
+			}
+			start("leader")
+			<-started
+			start("waiter")
+			waitWaiting(t, flight, "task", 1)
+			cancel()
+			<-done[tt.cancel]
+			close(release)
+			for _, d := range done {
+				<-d
+			}
+			if runs != tt.runs {
+				t.Errorf("got %d runs, want %d", runs, tt.runs)
+			}
+			for name, want := range tt.want {
+				if !errors.Is(errs[name], want) || (want == nil && errs[name] != nil) {
+					t.Errorf("%s: got error %v, want %v", name, errs[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSingleflightOf(t *testing.T) {
+	var (
+		flight  = NewSingleflight(NameKey(nil))
+		release = make(chan struct{})
+		runs    int32
+		wg      sync.WaitGroup
+		results = make([]int, 3)
+	)
+	task := SingleflightOf[int](concept.TaskFuncOf[int](func(ctx context.Context) (int, error) {
+		<-release
+		return int(atomic.AddInt32(&runs, 1)) * 42, nil
+	}), flight)
+	for i := range results {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = task.Do(namedCtx("task"))
+		}()
+	}
+	waitWaiting(t, flight, "task", len(results)-1)
+	close(release)
+	wg.Wait()
+	for i, result := range results {
+		if result != 42 {
+			t.Errorf("task %d: got %d, want 42", i, result)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package exe
+
+// NodeState is the state of a DAG node during a run.
+type NodeState int
+
+const (
+	// Pending nodes have not started, and wait for the nodes upstream of them
+	// or for a slot of the limiter.
+	Pending NodeState = iota
+	// Running nodes have been started.
+	Running
+	// Succeeded nodes returned no error.
+	Succeeded
+	// Failed nodes returned an error.
+	Failed
+	// Skipped nodes did not run, as their trigger rule did not hold.
+	Skipped
+	// UpstreamFailed nodes did not run because of a failure upstream of
+	// them.
+	UpstreamFailed
+	// Cancelled nodes were aborted, or never started, because the run was.
+	Cancelled
+)
+
+// String returns the name of the state.
+func (s NodeState) String() string {
+	switch s {
+	case Pending:
+		return "pending"
+	case Running:
+		return "running"
+	case Succeeded:
+		return "succeeded"
+	case Failed:
+		return "failed"
+	case Skipped:
+		return "skipped"
+	case UpstreamFailed:
+		return "upstream_failed"
+	case Cancelled:
+		return "cancelled"
+	default:
+		return "unknown"
+	}
+}
+
+// Done reports whether s is final.
+func (s NodeState) Done() bool {
+	return s >= Succeeded
+}
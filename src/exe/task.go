@@ -0,0 +1,168 @@
+package exe
+
+import (
+	"context"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+// Kinds of executor reported in concept.TaskInfo.
+const (
+	ExecutorSerial   = "serial"
+	ExecutorParallel = "parallel"
+	ExecutorDAG      = "dag"
+)
+
+// newTaskInfo describes the index-th child of the executor of the given kind,
+// with the given metadata, within the task described in ctx.
+func newTaskInfo(ctx context.Context, kind string, index int, meta concept.Meta) concept.TaskInfo {
+	parent, _ := GetTaskInfo(ctx)
+	path := make([]string, len(parent.Path), len(parent.Path)+1)
+	copy(path, parent.Path)
+	path = append(path, meta.Name)
+
+	return concept.TaskInfo{
+		Meta:     meta,
+		Path:     path,
+		Executor: kind,
+		Index:    index,
+		Attempt:  1,
+	}
+}
+
+// call runs the task described by info, wrapped by the AOP and the Aspect in
+// ctx. Panics are recovered according to the PanicPolicy in ctx and failures
+// are reported as *TaskError.
+func call(ctx context.Context, info concept.TaskInfo, task concept.Task) error {
+	_, err := invoke(ctx, info, task)
+	return err
+}
+
+// invoke is call, which also returns the number of attempts made. It emits
+// NodeStarted and NodeFinished.
+func invoke(ctx context.Context, info concept.TaskInfo, task concept.Task) (attempts int, err error) {
+	start := time.Now()
+	ctx, attempt := withAttempts(ctx, info.Attempt)
+	emitNode(ctx, NodeStarted, info, Running, nil)
+	defer func() {
+		attempts = attempt()
+		if err != nil {
+			err = newTaskError(info.Path, attempts, time.Since(start), err)
+		}
+		finished := info
+		finished.Attempt = attempts
+		emitNode(ctx, NodeFinished, finished, finishedState(ctx, err), err)
+	}()
+	defer recoverPanic(ctx, info.Path, &err)
+	f := GetAspect(ctx).Wrap(info, GetAOP(ctx).Apply(task.Do))
+	return 0, f(SetTaskInfo(ctx, info))
+}
+
+// recoverPanic stores the panic of the task at path in err as a *PanicError,
+// unless the PanicPolicy in ctx is RePanic. It must be deferred directly.
+func recoverPanic(ctx context.Context, path []string, err *error) {
+	if GetPanicPolicy(ctx) == RePanic {
+		return
+	}
+	if e := recover(); e != nil {
+		*err = &PanicError{
+			Name:  path[len(path)-1],
+			Path:  path,
+			Value: e,
+			Stack: debug.Stack(),
+		}
+	}
+}
+
+// run runs task with call once start got it a slot.
+func run(ctx context.Context, local *Limiter, t *ticket, info concept.TaskInfo, task concept.Task) error {
+	emitNode(ctx, NodeReady, info, Pending, nil)
+	release, err := start(ctx, local, t, task)
+	if err != nil {
+		emitNode(ctx, NodeSkipped, info, Cancelled, err)
+		return err
+	}
+	defer release()
+	return call(ctx, info, task)
+}
+
+// WithAOP returns a task that runs task wrapped by aops, such as a Retry, and
+// exposes the same metadata.
+func WithAOP(task concept.Task, aops ...concept.AOP) concept.Task {
+	return concept.WithMeta(aopTask{task: task, aop: concept.AOPs(aops)}, concept.MetaOf(task))
+}
+
+// aopTask is a task wrapped by an AOP.
+type aopTask struct {
+	task concept.Task
+	aop  concept.AOP
+}
+
+func (t aopTask) Do(ctx context.Context) error {
+	return t.aop.Apply(t.task.Do)(ctx)
+}
+
+// Unwrap returns the wrapped task.
+func (t aopTask) Unwrap() concept.Task {
+	return t.task
+}
+
+// executor is implemented by the tasks of this package that run other tasks.
+type executor interface {
+	executor()
+}
+
+// start waits for task to get a slot from its executor's limiter, through the
+// ticket reserved for it, and then from the Limiter in ctx, with the same
+// priority. Executors do not take slots from the Limiter in ctx, so nesting
+// them cannot deadlock.
+func start(ctx context.Context, local *Limiter, t *ticket, task concept.Task) (release func(), err error) {
+	if err = local.wait(ctx, t); err != nil {
+		return nil, err
+	}
+	global := GetLimiter(ctx)
+	if isExecutor(task) || global == local {
+		global = nil
+	}
+	var priority int
+	if t != nil {
+		priority = t.priority
+	}
+	if err = global.wait(ctx, global.reserve(priority)); err != nil {
+		local.Release()
+		return nil, err
+	}
+	return func() {
+		global.Release()
+		local.Release()
+	}, nil
+}
+
+// isExecutor reports whether task, or a task it decorates, is an executor.
+func isExecutor(task interface{}) bool {
+	for {
+		switch t := task.(type) {
+		case executor:
+			return true
+		case interface{ Unwrap() concept.Task }:
+			task = t.Unwrap()
+		case interface{ wrapped() interface{} }:
+			task = t.wrapped()
+		default:
+			return false
+		}
+	}
+}
+
+// childMeta returns the metadata of the index-th child of an executor, named
+// after its index if the task has no name.
+func childMeta(task concept.Task, index int) concept.Meta {
+	meta := concept.MetaOf(task)
+	if meta.Name == "" {
+		meta.Name = strconv.Itoa(index)
+	}
+	return meta
+}
@@ -0,0 +1,71 @@
+package exe
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+func TestAspect(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen []string
+	)
+	record := concept.AspectFunc(func(info concept.TaskInfo, f concept.TaskFunc) concept.TaskFunc {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			seen = append(seen, strings.Join(info.Path, "/")+":"+info.Executor+":"+info.Labels["kind"])
+			mu.Unlock()
+			return f(ctx)
+		}
+	})
+	leaf := T(func(ctx context.Context) error {
+		if info, ok := GetTaskInfo(ctx); !ok || info.Attempt != 1 {
+			t.Errorf("GetTaskInfo() = %+v, %v", info, ok)
+		}
+		return nil
+	})
+
+	b := NewDAGBuilder()
+	b.AddNode("db", concept.WithMeta(leaf, concept.Meta{Labels: map[string]string{"kind": "db"}}))
+	b.AddNode("fan-out", NewParallel(leaf, concept.WithName(NewSerial(leaf), "serial")))
+	d, err := b.Build()
+	if err != nil {
+		t.Errorf("DAG.Build() error = %v", err)
+		return
+	}
+
+	var applied bool
+	ctx := SetAspect(context.Background(), concept.Aspects{
+		record,
+		concept.AspectOf(&TestAOP{
+			f: func(f concept.TaskFunc) concept.TaskFunc {
+				mu.Lock()
+				applied = true
+				mu.Unlock()
+				return f
+			},
+		}),
+	})
+	if err := d.Do(ctx); err != nil {
+		t.Errorf("DAG.Do() error = %v", err)
+	}
+	sort.Strings(seen)
+	want := []string{
+		"db:dag:db",
+		"fan-out/0:parallel:",
+		"fan-out/serial/0:serial:",
+		"fan-out/serial:parallel:",
+		"fan-out:dag:",
+	}
+	if strings.Join(seen, ",") != strings.Join(want, ",") {
+		t.Errorf("Aspect saw %v, want %v", seen, want)
+	}
+	if !applied {
+		t.Errorf("adapted AOP was not applied")
+	}
+}
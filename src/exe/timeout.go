@@ -0,0 +1,29 @@
+package exe
+
+import (
+	"context"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+var (
+	_ concept.AOP = Timeout(0)
+)
+
+// Timeout is an AOP that bounds how long a task may run. The task is expected
+// to return once its context is done. A Timeout of zero or less does not
+// bound the task.
+type Timeout time.Duration
+
+// Apply returns a TaskFunc running f with a context that is done after t.
+func (t Timeout) Apply(f concept.TaskFunc) concept.TaskFunc {
+	if t <= 0 {
+		return f
+	}
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, time.Duration(t))
+		defer cancel()
+		return f(ctx)
+	}
+}
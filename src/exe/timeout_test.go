@@ -0,0 +1,46 @@
+package exe
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	wait := T(func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Millisecond * 50):
+			return nil
+		}
+	})
+	tests := []struct {
+		name    string
+		timeout Timeout
+		wantErr error
+	}{
+		{
+			name:    "expired",
+			timeout: Timeout(time.Millisecond),
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:    "in time",
+			timeout: Timeout(time.Second),
+			wantErr: nil,
+		},
+		{
+			name:    "unbounded",
+			timeout: Timeout(0),
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.timeout.Apply(wait.Do)(context.Background()); err != tt.wantErr {
+				t.Errorf("Timeout.Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package exe
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+func TestTriggerRule_decide(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  TriggerRule
+		c     upstreamCounts
+		total int
+		want  NodeState
+	}{
+		{"all_success waits", AllSuccess, upstreamCounts{done: 1, succeeded: 1}, 2, Pending},
+		{"all_success runs", AllSuccess, upstreamCounts{done: 2, succeeded: 2}, 2, Running},
+		{"all_success fails early", AllSuccess, upstreamCounts{done: 1, failed: 1}, 2, UpstreamFailed},
+		{"all_success skips", AllSuccess, upstreamCounts{done: 2, succeeded: 1, skipped: 1}, 2, Skipped},
+		{"all_done runs on failure", AllDone, upstreamCounts{done: 2, failed: 2}, 2, Running},
+		{"all_done waits", AllDone, upstreamCounts{done: 1, failed: 1}, 2, Pending},
+		{"one_success runs early", OneSuccess, upstreamCounts{done: 1, succeeded: 1}, 3, Running},
+		{"one_success fails", OneSuccess, upstreamCounts{done: 2, failed: 1, skipped: 1}, 2, UpstreamFailed},
+		{"one_success skips", OneSuccess, upstreamCounts{done: 2, skipped: 2}, 2, Skipped},
+		{"one_failed runs early", OneFailed, upstreamCounts{done: 1, failed: 1}, 3, Running},
+		{"one_failed skips", OneFailed, upstreamCounts{done: 2, succeeded: 2}, 2, Skipped},
+		{"none_failed runs after skip", NoneFailed, upstreamCounts{done: 2, succeeded: 1, skipped: 1}, 2, Running},
+		{"none_failed fails early", NoneFailed, upstreamCounts{done: 1, failed: 1}, 2, UpstreamFailed},
+		{"always waits", Always, upstreamCounts{done: 1, failed: 1}, 2, Pending},
+		{"always runs", Always, upstreamCounts{done: 2, failed: 1, skipped: 1}, 2, Running},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.decide(tt.c, tt.total); got != tt.want {
+				t.Errorf("TriggerRule.decide() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTriggerRule(t *testing.T) {
+	for r := AllSuccess; r <= Always; r++ {
+		got, err := ParseTriggerRule(r.String())
+		if err != nil || got != r {
+			t.Errorf("ParseTriggerRule(%q) = %v, %v", r.String(), got, err)
+		}
+	}
+	if _, err := ParseTriggerRule("sometimes"); err == nil {
+		t.Errorf("ParseTriggerRule(%q) error = nil", "sometimes")
+	}
+}
+
+func TestDAG_Trigger(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		ran map[string]bool
+	)
+	record := func(name string, err error) concept.Task {
+		return T(func(ctx context.Context) error {
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			ran[name] = ctx.Err() == nil
+			mu.Unlock()
+			return err
+		})
+	}
+
+	tests := []struct {
+		name    string
+		mode    FailureMode
+		build   func(b *DAGBuilder)
+		ran     []string
+		skipped []string
+	}{
+		{
+			name: "cleanup runs on abort",
+			mode: FailFast,
+			build: func(b *DAGBuilder) {
+				b.AddNode("fail", record("fail", errTest), "next")
+				b.AddNode("next", record("next", nil))
+				b.AddNodeWithOptions("cleanup", record("cleanup", nil), DependsOn("fail", "next"), WithTrigger(Always))
+			},
+			ran:     []string{"fail", "cleanup"},
+			skipped: []string{"next"},
+		},
+		{
+			name: "notify on failure",
+			mode: CollectAll,
+			build: func(b *DAGBuilder) {
+				b.AddNode("ok", record("ok", nil))
+				b.AddNode("fail", record("fail", errTest))
+				b.AddNodeWithOptions("notify", record("notify", nil), DependsOn("ok", "fail"), WithTrigger(OneFailed))
+				b.AddNodeWithOptions("after", record("after", nil), DependsOn("ok", "fail"))
+				b.AddNodeWithOptions("after-after", record("after-after", nil), DependsOn("after"), WithTrigger(AllDone))
+			},
+			ran:     []string{"ok", "fail", "notify", "after-after"},
+			skipped: []string{"after"},
+		},
+		{
+			name: "skips propagate",
+			mode: FailFast,
+			build: func(b *DAGBuilder) {
+				b.AddNode("ok", record("ok", nil))
+				b.AddNodeWithOptions("notify", record("notify", nil), DependsOn("ok"), WithTrigger(OneFailed))
+				b.AddNodeWithOptions("after-notify", record("after-notify", nil), DependsOn("notify"))
+				b.AddNodeWithOptions("report", record("report", nil), DependsOn("ok", "notify"), WithTrigger(NoneFailed))
+			},
+			ran:     []string{"ok", "report"},
+			skipped: []string{"notify", "after-notify"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran = map[string]bool{}
+			b := NewDAGBuilder()
+			tt.build(b)
+			d, err := b.Build()
+			if err != nil {
+				t.Errorf("DAG.Build() error = %v", err)
+				return
+			}
+			err = d.WithMode(tt.mode).Do(context.Background())
+			if !errors.Is(err, errTest) && ran["fail"] {
+				t.Errorf("DAG.Do() error = %v, want %v", err, errTest)
+			}
+			for _, name := range tt.ran {
+				if !ran[name] {
+					t.Errorf("DAG.Do() did not run %s with a live context, ran %v", name, ran)
+				}
+			}
+			for _, name := range tt.skipped {
+				if _, ok := ran[name]; ok {
+					t.Errorf("DAG.Do() ran %s", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+	"github.com/SakuraSa/ge/src/exe"
+)
+
+var (
+	ErrInvalidSpec = errors.New("invalid DAG definition")
+)
+
+// Build builds the DAG defined by s, with tasks made by the factories of
+// registry, or of DefaultRegistry if it is nil. The DAG is checked by the
+// DAGCheckers, like any other.
+func (s Spec) Build(registry *Registry) (exe.DAG, error) {
+	if registry == nil {
+		registry = DefaultRegistry
+	}
+	mode, err := exe.ParseFailureMode(orDefault(s.Mode, exe.FailFast.String()))
+	if err != nil {
+		return exe.DAG{}, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+
+	builder := exe.NewDAGBuilder()
+	seen := make(map[string]bool, len(s.Nodes))
+	for _, node := range s.Nodes {
+		if node.Name == "" {
+			return exe.DAG{}, fmt.Errorf("%w: node without a name", ErrInvalidSpec)
+		}
+		if seen[node.Name] {
+			return exe.DAG{}, fmt.Errorf("%w: node %s defined twice", ErrInvalidSpec, node.Name)
+		}
+		seen[node.Name] = true
+
+		task, opts, err := node.build(registry)
+		if err != nil {
+			return exe.DAG{}, fmt.Errorf("node %s: %w", node.Name, err)
+		}
+		builder.AddNodeWithOptions(node.Name, task, opts...)
+	}
+
+	dag, err := builder.Build()
+	if err != nil {
+		return exe.DAG{}, err
+	}
+	dag = dag.WithMode(mode).WithGracePeriod(time.Duration(s.GracePeriod))
+	if s.Concurrency > 0 {
+		dag = dag.WithLimiter(exe.NewLimiter(s.Concurrency))
+	}
+	return dag, nil
+}
+
+// build returns the task of the node and its options.
+func (n NodeSpec) build(registry *Registry) (concept.Task, []exe.NodeOption, error) {
+	task, err := registry.New(n.Type, n.Params)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n.Description != "" {
+		meta := concept.MetaOf(task)
+		meta.Description = n.Description
+		task = concept.WithMeta(task, meta)
+	}
+
+	policy, err := exe.ParseFailurePolicy(orDefault(n.OnFailure, exe.FailRun.String()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+	trigger, err := exe.ParseTriggerRule(orDefault(n.Trigger, exe.AllSuccess.String()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+
+	opts := []exe.NodeOption{
+		exe.DependsOn(n.DependsOn...),
+		exe.Before(n.Before...),
+		exe.WithTimeout(time.Duration(n.Timeout)),
+		exe.WithPriority(n.Priority),
+		exe.WithLabels(n.Labels),
+		exe.WithFailurePolicy(policy),
+		exe.WithTrigger(trigger),
+	}
+	if n.Retry != nil {
+		opts = append(opts, exe.WithRetry(n.Retry.build()))
+	}
+	if n.Inputs != nil {
+		opts = append(opts, exe.WithInputs(n.inputs()...))
+	}
+	return task, opts, nil
+}
+
+// inputs returns the inputs of the node: the ones defined, and its type and
+// params.
+func (n NodeSpec) inputs() []exe.Input {
+	inputs := []exe.Input{
+		exe.ParamInput("type", n.Type),
+		exe.ParamInput("params", n.Params),
+	}
+	if len(n.Inputs.Files) > 0 {
+		inputs = append(inputs, exe.FileInput(n.Inputs.Files...))
+	}
+	keys := make([]string, 0, len(n.Inputs.Params))
+	for k := range n.Inputs.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		inputs = append(inputs, exe.ParamInput(k, n.Inputs.Params[k]))
+	}
+	return inputs
+}
+
+// build returns the Retry defined by r.
+func (r RetrySpec) build() exe.Retry {
+	var opts []exe.RetryOption
+	if r.MaxAttempts != 0 {
+		opts = append(opts, exe.RetryMaxAttempts(r.MaxAttempts))
+	}
+	if r.MaxElapsed > 0 {
+		opts = append(opts, exe.RetryMaxElapsed(time.Duration(r.MaxElapsed)))
+	}
+	var backoff exe.Backoff
+	switch {
+	case r.Multiplier > 0:
+		backoff = exe.ExponentialBackoff(time.Duration(r.Backoff), time.Duration(r.MaxDelay), r.Multiplier)
+	case r.Backoff > 0:
+		backoff = exe.FixedBackoff(time.Duration(r.Backoff))
+	}
+	if backoff != nil && r.Jitter > 0 {
+		backoff = exe.JitteredBackoff(backoff, r.Jitter)
+	}
+	if backoff != nil {
+		opts = append(opts, exe.RetryBackoff(backoff))
+	}
+	return exe.NewRetry(opts...)
+}
+
+// Load builds the DAG defined in the file at path, with tasks made by the
+// factories of registry, or of DefaultRegistry if it is nil.
+func Load(path string, registry *Registry) (exe.DAG, error) {
+	spec, err := ParseFile(path)
+	if err != nil {
+		return exe.DAG{}, err
+	}
+	return spec.Build(registry)
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
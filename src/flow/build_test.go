@@ -0,0 +1,172 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+	"github.com/SakuraSa/ge/src/exe"
+)
+
+func TestSpec_Build(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		ran []string
+	)
+	registry := NewRegistry()
+	registry.Register("record", func(params Params) (concept.Task, error) {
+		var p struct {
+			Fail bool `json:"fail"`
+		}
+		if err := params.Decode(&p); err != nil {
+			return nil, err
+		}
+		return concept.TaskFunc(func(ctx context.Context) error {
+			info, _ := exe.GetTaskInfo(ctx)
+			mu.Lock()
+			ran = append(ran, info.Name)
+			mu.Unlock()
+			if p.Fail {
+				return errors.New("failed")
+			}
+			return nil
+		}), nil
+	})
+
+	t.Run("run", func(t *testing.T) {
+		ran = nil
+		spec := Spec{
+			Mode: "collect-all",
+			Nodes: []NodeSpec{
+				{Name: "extract-a", Type: "record"},
+				{Name: "extract-b", Type: "record", Params: Params{"fail": true}, OnFailure: "ignore-failure"},
+				{Name: "load", Type: "record", DependsOn: []string{"^extract-"}, Description: "loads"},
+				{Name: "cleanup", Type: "record", DependsOn: []string{"load"}, Trigger: "always"},
+			},
+		}
+		d, err := spec.Build(registry)
+		if err != nil {
+			t.Errorf("Spec.Build() error = %v", err)
+			return
+		}
+		if err := d.Do(context.Background()); err != nil {
+			t.Errorf("DAG.Do() error = %v", err)
+		}
+		if len(ran) != 4 || ran[2] != "load" || ran[3] != "cleanup" {
+			t.Errorf("DAG.Do() ran %v", ran)
+		}
+		for _, meta := range d.Nodes() {
+			if meta.Name == "load" && meta.Description != "loads" {
+				t.Errorf("DAG.Nodes() load = %+v", meta)
+			}
+		}
+	})
+
+	t.Run("retry and inputs", func(t *testing.T) {
+		ran = nil
+		dir := t.TempDir()
+		input := filepath.Join(dir, "input.txt")
+		if err := os.WriteFile(input, []byte("v1"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		spec := Spec{
+			Nodes: []NodeSpec{
+				{Name: "flaky", Type: "record", Params: Params{"fail": true}, OnFailure: "ignore-failure",
+					Retry: &RetrySpec{MaxAttempts: 3, Backoff: Duration(time.Millisecond), Multiplier: 2, Jitter: 0.5}},
+				{Name: "fixed", Type: "record", Params: Params{"fail": true}, OnFailure: "ignore-failure",
+					Retry: &RetrySpec{MaxAttempts: 2, MaxElapsed: Duration(time.Second), Backoff: Duration(time.Millisecond)}},
+				{Name: "build", Type: "record",
+					Inputs: &InputSpec{Files: []string{input}, Params: map[string]interface{}{"date": "2024-01-01", "env": "prod"}}},
+			},
+		}
+		d, err := spec.Build(registry)
+		if err != nil {
+			t.Errorf("Spec.Build() error = %v", err)
+			return
+		}
+		d = d.WithFingerprintStore(exe.NewFileFingerprintStore(filepath.Join(dir, "state")), "retry")
+		for i := 0; i < 2; i++ {
+			if err := d.Do(context.Background()); err != nil {
+				t.Errorf("DAG.Do() error = %v", err)
+			}
+		}
+		counts := map[string]int{}
+		for _, name := range ran {
+			counts[name]++
+		}
+		if counts["flaky"] != 6 || counts["fixed"] != 4 || counts["build"] != 1 {
+			t.Errorf("DAG.Do() ran %v", counts)
+		}
+	})
+
+	tests := []struct {
+		name string
+		spec Spec
+		want error
+	}{
+		{"unknown type", Spec{Nodes: []NodeSpec{{Name: "a", Type: "teleport"}}}, ErrUnknownType},
+		{"duplicate", Spec{Nodes: []NodeSpec{{Name: "a", Type: "record"}, {Name: "a", Type: "record"}}}, ErrInvalidSpec},
+		{"no name", Spec{Nodes: []NodeSpec{{Type: "record"}}}, ErrInvalidSpec},
+		{"bad trigger", Spec{Nodes: []NodeSpec{{Name: "a", Type: "record", Trigger: "sometimes"}}}, ErrInvalidSpec},
+		{"bad mode", Spec{Mode: "yolo"}, ErrInvalidSpec},
+		{"cycle", Spec{Nodes: []NodeSpec{
+			{Name: "a", Type: "record", DependsOn: []string{"b"}},
+			{Name: "b", Type: "record", DependsOn: []string{"a"}},
+		}}, exe.ErrCycle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.spec.Build(registry); !errors.Is(err, tt.want) {
+				t.Errorf("Spec.Build() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	tests := []struct {
+		typ     string
+		params  Params
+		cancel  bool
+		newErr  bool
+		wantErr bool
+	}{
+		{"noop", nil, false, false, false},
+		{"noop", Params{"what": 1}, false, true, false},
+		{"sleep", Params{"duration": "1ms"}, false, false, false},
+		{"sleep", Params{"duration": "1h"}, true, false, true},
+		{"fail", Params{"message": "boom"}, false, false, true},
+		{"exec", Params{"command": []string{"true"}}, false, false, false},
+		{"exec", Params{"command": []string{"false"}}, false, false, true},
+		{"exec", Params{"command": []string{"sh", "-c", `test "$GE_TEST" = ok`}, "env": map[string]string{"GE_TEST": "ok"}}, false, false, false},
+		{"exec", Params{"command": []string{"sh", "-c", `test "$GE_TEST" = ok`}}, false, false, true},
+		{"exec", Params{"command": []string{"pwd"}, "dir": "/nonexistent"}, false, false, true},
+		{"exec", Params{"command": []string{"sleep", "60"}}, true, false, true},
+		{"exec", nil, false, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+			task, err := DefaultRegistry.New(tt.typ, tt.params)
+			if (err != nil) != tt.newErr {
+				t.Errorf("Registry.New() error = %v, wantErr %v", err, tt.newErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if err := task.Do(ctx); (err != nil) != tt.wantErr {
+				t.Errorf("Task.Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+// registerBuiltins registers the builtin task types in r:
+//
+//   - noop does nothing.
+//   - sleep waits for its duration param, or until its context is done.
+//   - fail fails with its message param.
+//   - exec runs its command param, a list of strings, in its dir param,
+//     with its env param added to the environment, and with the output of
+//     the command going to the one of the program.
+func registerBuiltins(r *Registry) {
+	r.Register("noop", newNoop)
+	r.Register("sleep", newSleep)
+	r.Register("fail", newFail)
+	r.Register("exec", newExec)
+}
+
+func newNoop(params Params) (concept.Task, error) {
+	if err := params.Decode(&struct{}{}); err != nil {
+		return nil, err
+	}
+	return concept.TaskFunc(func(ctx context.Context) error {
+		return nil
+	}), nil
+}
+
+func newSleep(params Params) (concept.Task, error) {
+	var p struct {
+		Duration Duration `json:"duration"`
+	}
+	if err := params.Decode(&p); err != nil {
+		return nil, err
+	}
+	return concept.TaskFunc(func(ctx context.Context) error {
+		timer := time.NewTimer(time.Duration(p.Duration))
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		}
+	}), nil
+}
+
+func newFail(params Params) (concept.Task, error) {
+	var p struct {
+		Message string `json:"message"`
+	}
+	if err := params.Decode(&p); err != nil {
+		return nil, err
+	}
+	if p.Message == "" {
+		p.Message = "failed"
+	}
+	return concept.TaskFunc(func(ctx context.Context) error {
+		return errors.New(p.Message)
+	}), nil
+}
+
+func newExec(params Params) (concept.Task, error) {
+	var p struct {
+		Command []string          `json:"command"`
+		Dir     string            `json:"dir"`
+		Env     map[string]string `json:"env"`
+	}
+	if err := params.Decode(&p); err != nil {
+		return nil, err
+	}
+	if len(p.Command) == 0 {
+		return nil, errors.New("exec needs a command")
+	}
+	return concept.TaskFunc(func(ctx context.Context) error {
+		cmd := exec.CommandContext(ctx, p.Command[0], p.Command[1:]...)
+		cmd.Dir = p.Dir
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if len(p.Env) > 0 {
+			cmd.Env = os.Environ()
+			for k, v := range p.Env {
+				cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+			}
+		}
+		return cmd.Run()
+	}), nil
+}
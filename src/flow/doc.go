@@ -0,0 +1,4 @@
+// flow
+// Package flow builds DAGs from declarative definitions, written in YAML or
+// JSON, whose nodes are tasks made by the factories of a Registry.
+package flow
@@ -0,0 +1,96 @@
+package flow
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/SakuraSa/ge/src/concept"
+)
+
+var (
+	ErrUnknownType = errors.New("unknown task type")
+)
+
+// Params are the parameters of a task, as decoded from a definition.
+type Params map[string]interface{}
+
+// Decode decodes p into v, a pointer to a struct whose fields have json tags,
+// as if p had been written in JSON. Unknown parameters are errors.
+func (p Params) Decode(v interface{}) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// Factory makes a task from its parameters.
+type Factory func(params Params) (concept.Task, error)
+
+// Registry maps task types to the factories making them.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]Factory)}
+}
+
+// Register registers factory as the one making the tasks of type typ. It
+// panics if typ is already registered.
+func (r *Registry) Register(typ string, factory Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.factories[typ]; ok {
+		panic(fmt.Sprintf("flow: task type %q registered twice", typ))
+	}
+	r.factories[typ] = factory
+}
+
+// New makes a task of type typ from params.
+func (r *Registry) New(typ string, params Params) (concept.Task, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[typ]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownType, typ)
+	}
+	task, err := factory(params)
+	if err != nil {
+		return nil, fmt.Errorf("task type %q: %w", typ, err)
+	}
+	return task, nil
+}
+
+// Types returns the registered task types, sorted.
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]string, 0, len(r.factories))
+	for typ := range r.factories {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// DefaultRegistry is the Registry of the package-level functions. It comes
+// with the builtin task types.
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	registerBuiltins(DefaultRegistry)
+}
+
+// Register registers factory in DefaultRegistry.
+func Register(typ string, factory Factory) {
+	DefaultRegistry.Register(typ, factory)
+}
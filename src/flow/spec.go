@@ -0,0 +1,170 @@
+package flow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is the definition of a DAG.
+//
+// In YAML:
+//
+//	name: nightly
+//	mode: collect-all
+//	concurrency: 4
+//	nodes:
+//	  - name: fetch
+//	    type: exec
+//	    params:
+//	      command: [curl, -o, data.json, https://example.com/data]
+//	    retry:
+//	      max_attempts: 3
+//	      backoff: 1s
+//	  - name: report
+//	    type: exec
+//	    depends_on: [fetch]
+//	    trigger: all_done
+//	    params:
+//	      command: [./report.sh]
+type Spec struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Mode is the FailureMode of the DAG, such as "collect-all".
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// Concurrency bounds the number of nodes running at a time.
+	Concurrency int `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+	// GracePeriod bounds how long an aborted run waits for its nodes.
+	GracePeriod Duration   `json:"grace_period,omitempty" yaml:"grace_period,omitempty"`
+	Nodes       []NodeSpec `json:"nodes" yaml:"nodes"`
+}
+
+// NodeSpec is the definition of a node of a DAG.
+type NodeSpec struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Type is the name the factory of the task is registered with.
+	Type string `json:"type" yaml:"type"`
+	// Params are handed to the factory of the task.
+	Params Params `json:"params,omitempty" yaml:"params,omitempty"`
+	// DependsOn and Before list the nodes, named or matching as a regexp,
+	// the node runs after and before.
+	DependsOn []string          `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+	Before    []string          `json:"before,omitempty" yaml:"before,omitempty"`
+	Timeout   Duration          `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Retry     *RetrySpec        `json:"retry,omitempty" yaml:"retry,omitempty"`
+	Priority  int               `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	// OnFailure is the FailurePolicy of the node, such as "continue-run".
+	OnFailure string `json:"on_failure,omitempty" yaml:"on_failure,omitempty"`
+	// Trigger is the TriggerRule of the node, such as "all_done".
+	Trigger string `json:"trigger,omitempty" yaml:"trigger,omitempty"`
+	// Inputs make the node skipped while they, its type and its params do
+	// not change, when the DAG has a FingerprintStore.
+	Inputs *InputSpec `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+}
+
+// InputSpec is the definition of the inputs of a node.
+type InputSpec struct {
+	// Files are the files, or the glob patterns matching them, the node
+	// reads.
+	Files []string `json:"files,omitempty" yaml:"files,omitempty"`
+	// Params are values the node depends on, such as a date.
+	Params map[string]interface{} `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// RetrySpec is the definition of the retries of a node. Without a
+// multiplier, the node waits Backoff between attempts; with one, the delay
+// grows exponentially up to MaxDelay.
+type RetrySpec struct {
+	MaxAttempts int      `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"`
+	MaxElapsed  Duration `json:"max_elapsed,omitempty" yaml:"max_elapsed,omitempty"`
+	Backoff     Duration `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	MaxDelay    Duration `json:"max_delay,omitempty" yaml:"max_delay,omitempty"`
+	Multiplier  float64  `json:"multiplier,omitempty" yaml:"multiplier,omitempty"`
+	Jitter      float64  `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+}
+
+// Duration is a time.Duration written as a string, such as "1m30s".
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Format is the format of a definition.
+type Format string
+
+const (
+	JSON Format = "json"
+	YAML Format = "yaml"
+)
+
+// FormatOf returns the format of the file at path, from its extension.
+func FormatOf(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON, nil
+	case ".yaml", ".yml":
+		return YAML, nil
+	default:
+		return "", fmt.Errorf("unknown format of %s", path)
+	}
+}
+
+// Parse decodes a definition. Unknown fields are errors, so that typos do
+// not go unnoticed.
+func Parse(data []byte, format Format) (Spec, error) {
+	var spec Spec
+	switch format {
+	case JSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&spec); err != nil {
+			return Spec{}, err
+		}
+	case YAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&spec); err != nil {
+			return Spec{}, err
+		}
+	default:
+		return Spec{}, fmt.Errorf("unknown format %q", format)
+	}
+	return spec, nil
+}
+
+// ParseFile decodes the definition in the file at path, in the format of its
+// extension.
+func ParseFile(path string) (Spec, error) {
+	format, err := FormatOf(path)
+	if err != nil {
+		return Spec{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Spec{}, err
+	}
+	spec, err := Parse(data, format)
+	if err != nil {
+		return Spec{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec, nil
+}
@@ -0,0 +1,99 @@
+package flow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testYAML = `
+name: nightly
+mode: collect-all
+concurrency: 2
+nodes:
+  - name: fetch
+    type: sleep
+    params:
+      duration: 1ms
+    retry:
+      max_attempts: 2
+      backoff: 10ms
+  - name: report
+    type: noop
+    depends_on: [fetch]
+    trigger: all_done
+    timeout: 1m30s
+`
+
+const testJSON = `{
+  "name": "nightly",
+  "mode": "collect-all",
+  "concurrency": 2,
+  "nodes": [
+    {"name": "fetch", "type": "sleep", "params": {"duration": "1ms"}, "retry": {"max_attempts": 2, "backoff": "10ms"}},
+    {"name": "report", "type": "noop", "depends_on": ["fetch"], "trigger": "all_done", "timeout": "1m30s"}
+  ]
+}`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		format  Format
+		wantErr bool
+	}{
+		{"yaml", testYAML, YAML, false},
+		{"json", testJSON, JSON, false},
+		{"unknown yaml field", "nodes:\n  - name: a\n    typ: noop\n", YAML, true},
+		{"unknown json field", `{"nodes": [{"name": "a", "typ": "noop"}]}`, JSON, true},
+		{"output inputs", "nodes:\n  - name: a\n    type: noop\n    inputs:\n      outputs: [b]\n", YAML, true},
+		{"bad duration", "nodes:\n  - name: a\n    type: noop\n    timeout: soon\n", YAML, true},
+		{"unknown format", "{}", Format("toml"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := Parse([]byte(tt.data), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if spec.Name != "nightly" || spec.Mode != "collect-all" || spec.Concurrency != 2 || len(spec.Nodes) != 2 {
+				t.Errorf("Parse() = %+v", spec)
+				return
+			}
+			fetch, report := spec.Nodes[0], spec.Nodes[1]
+			if fetch.Retry == nil || fetch.Retry.MaxAttempts != 2 || time.Duration(fetch.Retry.Backoff) != 10*time.Millisecond {
+				t.Errorf("Parse() fetch = %+v", fetch)
+			}
+			if fetch.Params["duration"] != "1ms" {
+				t.Errorf("Parse() fetch params = %v", fetch.Params)
+			}
+			if time.Duration(report.Timeout) != 90*time.Second || report.Trigger != "all_done" || report.DependsOn[0] != "fetch" {
+				t.Errorf("Parse() report = %+v", report)
+			}
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{"flow.yml": testYAML, "flow.json": testJSON} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if spec, err := ParseFile(path); err != nil || len(spec.Nodes) != 2 {
+			t.Errorf("ParseFile(%q) = %+v, %v", name, spec, err)
+		}
+		if d, err := Load(path, nil); err != nil || len(d.Nodes()) != 2 {
+			t.Errorf("Load(%q) error = %v", name, err)
+		}
+	}
+	if _, err := ParseFile(filepath.Join(dir, "flow.txt")); err == nil {
+		t.Errorf("ParseFile() of an unknown format error = nil")
+	}
+}
@@ -11,3 +11,18 @@ func IsUniqe[T comparable](s []T) bool {
 	}
 	return true
 }
+
+// Uniq returns the slice without its duplicates, in the order they first
+// appear. It reuses the storage of s.
+func Uniq[T comparable](s []T) []T {
+	m := make(map[T]struct{}, len(s))
+	out := s[:0]
+	for _, v := range s {
+		if _, ok := m[v]; ok {
+			continue
+		}
+		m[v] = struct{}{}
+		out = append(out, v)
+	}
+	return out
+}